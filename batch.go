@@ -0,0 +1,167 @@
+package nip44
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// BatchItem is one message of a batch. The conversation key is either given directly
+// or derived from the private and public key. Items with the same keys share the derivation.
+type BatchItem struct {
+	PrivateKey      []byte
+	PublicKey       []byte
+	ConversationKey []byte
+	// Data is the plaintext for EncryptBatch and the payload for DecryptBatch.
+	Data string
+}
+
+// BatchResult is the result of the item at the same index. Data is the payload for
+// EncryptBatch and the plaintext for DecryptBatch.
+type BatchResult struct {
+	Data string
+	Err  error
+}
+
+// BatchWorkers is the number of goroutines used per batch. Zero means runtime.GOMAXPROCS(0).
+var BatchWorkers = 0
+
+// DecryptBatch decrypts all items in parallel. A failing item does not fail the batch.
+// Items which were not processed before ctx was done fail with the error of ctx.
+func DecryptBatch(ctx context.Context, items []BatchItem) []BatchResult {
+	return runBatch(ctx, items, func(c *Cipher, key *ConversationKey, data string) ([]byte, error) {
+		payload := []byte(data)
+		scheme, err := payloadScheme(payload)
+		if err != nil {
+			return nil, err
+		}
+		if cs, ok := scheme.(cipherScheme); ok {
+			return cs.appendDecryptCipher(c, nil, payload)
+		}
+		return scheme.AppendDecrypt(nil, key[:], payload)
+	})
+}
+
+// EncryptBatch encrypts all items in parallel, see DecryptBatch. The Salt option is not
+// supported since salts must not be reused.
+func EncryptBatch(ctx context.Context, items []BatchItem, options ...Option) []BatchResult {
+	var (
+		o      = applyOptions(options)
+		scheme Scheme
+		err    error
+		ok     bool
+	)
+	if o.Version == 0 {
+		o.Version = DefaultVersion
+	}
+	if scheme, ok = LookupScheme(o.Version); !ok {
+		err = &Error{Err: ErrUnknownVersion, Version: o.Version}
+	}
+	if o.Salt != nil {
+		err = ErrSaltNotAllowed
+	}
+	if err != nil {
+		results := make([]BatchResult, len(items))
+		for i := range results {
+			results[i].Err = err
+		}
+		return results
+	}
+	return runBatch(ctx, items, func(c *Cipher, key *ConversationKey, data string) ([]byte, error) {
+		plaintext := []byte(data)
+		if err := checkPlaintextLen(scheme, plaintext); err != nil {
+			return nil, err
+		}
+		if cs, ok := scheme.(cipherScheme); ok {
+			return cs.appendEncryptCipher(c, nil, plaintext, o)
+		}
+		return scheme.AppendEncrypt(nil, key[:], plaintext, o)
+	})
+}
+
+// batchGroup is the conversation key shared by items with the same keys.
+type batchGroup struct {
+	once sync.Once
+	key  ConversationKey
+	err  error
+}
+
+type batchGroupID struct {
+	id     [32]byte
+	direct bool
+}
+
+func runBatch(ctx context.Context, items []BatchItem, f func(*Cipher, *ConversationKey, string) ([]byte, error)) []BatchResult {
+	var (
+		results    = make([]BatchResult, len(items))
+		groups     = make(map[batchGroupID]*batchGroup)
+		itemGroups = make([]*batchGroup, len(items))
+		workers    = BatchWorkers
+		next       atomic.Int64
+		wg         sync.WaitGroup
+	)
+	for i, item := range items {
+		id := batchGroupID{id: cacheID(item.PrivateKey, item.PublicKey)}
+		if item.ConversationKey != nil {
+			id = batchGroupID{id: cacheID(item.ConversationKey, nil), direct: true}
+		}
+		if groups[id] == nil {
+			groups[id] = &batchGroup{}
+		}
+		itemGroups[i] = groups[id]
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(items))
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := cipherPool.Get().(*Cipher)
+			defer cipherPool.Put(c)
+			defer c.Wipe()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(items) {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i] = runBatchItem(c, &items[i], itemGroups[i], f)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, g := range groups {
+		g.key.Wipe()
+	}
+	return results
+}
+
+func runBatchItem(c *Cipher, item *BatchItem, g *batchGroup, f func(*Cipher, *ConversationKey, string) ([]byte, error)) BatchResult {
+	var (
+		b   []byte
+		err error
+	)
+	g.once.Do(func() {
+		if item.ConversationKey != nil {
+			g.key, g.err = ConversationKeyFromBytes(item.ConversationKey)
+		} else {
+			g.key, g.err = NewConversationKey(item.PrivateKey, item.PublicKey)
+		}
+	})
+	if g.err != nil {
+		return BatchResult{Err: g.err}
+	}
+	if err = c.Reset(g.key[:]); err != nil {
+		return BatchResult{Err: err}
+	}
+	if b, err = f(c, &g.key, item.Data); err != nil {
+		return BatchResult{Err: err}
+	}
+	return BatchResult{Data: string(b)}
+}
@@ -0,0 +1,123 @@
+package nip44_test
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatch(t *testing.T) {
+	var (
+		sk1, _     = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000001")
+		sk2, pub2  = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000002")
+		_, pub1    = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000001")
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		items      = make([]nip44.BatchItem, 100)
+		results    []nip44.BatchResult
+	)
+	for i := range items {
+		items[i] = nip44.BatchItem{PrivateKey: sk1, PublicKey: pub2, Data: fmt.Sprintf("message %d", i)}
+	}
+	results = nip44.EncryptBatch(context.Background(), items)
+	for i, r := range results {
+		if assert.NoErrorf(t, r.Err, "encryption failed: %v", r.Err) {
+			items[i] = nip44.BatchItem{PrivateKey: sk2, PublicKey: pub1, Data: r.Data}
+		}
+	}
+	// the conversation key can also be given directly
+	items[1] = nip44.BatchItem{ConversationKey: convKey, Data: items[1].Data}
+	items[2].Data = "invalid"
+	items[3].PrivateKey = make([]byte, 32)
+	results = nip44.DecryptBatch(context.Background(), items)
+	for i, r := range results {
+		switch i {
+		case 2:
+			assert.ErrorIs(t, r.Err, nip44.ErrInvalidPayloadLength)
+		case 3:
+			assert.ErrorIs(t, r.Err, nip44.ErrInvalidPrivateKey)
+		default:
+			if assert.NoErrorf(t, r.Err, "decryption of item %d failed: %v", i, r.Err) {
+				assert.Equal(t, fmt.Sprintf("message %d", i), r.Data)
+			}
+		}
+	}
+}
+
+func TestBatchFail(t *testing.T) {
+	var (
+		sk1, _      = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000001")
+		_, pub2     = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000002")
+		items       = []nip44.BatchItem{{PrivateKey: sk1, PublicKey: pub2, Data: "a"}, {PrivateKey: sk1, PublicKey: pub2, Data: "b"}}
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	cancel()
+	for _, r := range nip44.EncryptBatch(ctx, items) {
+		assert.ErrorIs(t, r.Err, context.Canceled)
+	}
+	for _, r := range nip44.EncryptBatch(context.Background(), items, nip44.WithSalt(make([]byte, 32))) {
+		assert.ErrorIs(t, r.Err, nip44.ErrSaltNotAllowed)
+	}
+	for _, r := range nip44.EncryptBatch(context.Background(), items, nip44.WithVersion(3)) {
+		assertError(t, &nip44.Error{Err: nip44.ErrUnknownVersion, Version: 3}, r.Err)
+	}
+	items[1].Data = ""
+	results := nip44.EncryptBatch(context.Background(), items)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[1].Err, nip44.ErrInvalidPlaintextLength)
+	assert.Empty(t, nip44.DecryptBatch(context.Background(), nil))
+}
+
+func TestBatchScheme(t *testing.T) {
+	var (
+		sk1, pub1 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000001")
+		sk2, pub2 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000002")
+		items     = []nip44.BatchItem{{PrivateKey: sk1, PublicKey: pub2, Data: "a"}, {PrivateKey: sk1, PublicKey: pub2, Data: "b"}}
+		results   []nip44.BatchResult
+	)
+	nip44.RegisterScheme(3, plainScheme{version: 3})
+	defer nip44.UnregisterScheme(3)
+
+	results = nip44.EncryptBatch(context.Background(), items[:1], nip44.WithVersion(3))
+	if assert.NoErrorf(t, results[0].Err, "encryption failed: %v", results[0].Err) {
+		assert.Equal(t, "A2E=", results[0].Data)
+		items[0].Data = results[0].Data
+	}
+	results = nip44.EncryptBatch(context.Background(), items[1:])
+	if assert.NoErrorf(t, results[0].Err, "encryption failed: %v", results[0].Err) {
+		items[1].Data = results[0].Data
+	}
+	// payloads of both versions in one batch
+	for i := range items {
+		items[i].PrivateKey, items[i].PublicKey = sk2, pub1
+	}
+	results = nip44.DecryptBatch(context.Background(), items)
+	for i, expected := range []string{"a", "b"} {
+		if assert.NoErrorf(t, results[i].Err, "decryption failed: %v", results[i].Err) {
+			assert.Equal(t, expected, results[i].Data)
+		}
+	}
+}
+
+func BenchmarkDecryptBatch(b *testing.B) {
+	var (
+		sk1, _  = testKeyPair(b, "0000000000000000000000000000000000000000000000000000000000000001")
+		_, pub2 = testKeyPair(b, "0000000000000000000000000000000000000000000000000000000000000002")
+		items   = make([]nip44.BatchItem, 100)
+	)
+	for i := range items {
+		items[i] = nip44.BatchItem{PrivateKey: sk1, PublicKey: pub2, Data: "hello"}
+	}
+	results := nip44.EncryptBatch(context.Background(), items)
+	for i := range items {
+		items[i].Data = results[i].Data
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nip44.DecryptBatch(context.Background(), items)
+	}
+}
@@ -0,0 +1,155 @@
+package nip44
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// ConversationKeyCache is a concurrency-safe LRU cache of conversation keys. Keys are wiped
+// when they are evicted, expire or the cache is purged.
+type ConversationKeyCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	lru     *list.List
+	entries map[[32]byte]*list.Element
+	stats   CacheStats
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	id      [32]byte
+	key     ConversationKey
+	expires time.Time
+}
+
+// NewConversationKeyCache returns a cache which holds at most size keys for at most ttl.
+// A size or ttl <= 0 means no limit.
+func NewConversationKeyCache(size int, ttl time.Duration) *ConversationKeyCache {
+	return &ConversationKeyCache{
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: make(map[[32]byte]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get returns the conversation key for the given keys, generating it on a cache miss.
+func (c *ConversationKeyCache) Get(sendPrivkey []byte, recvPubkey []byte) (ConversationKey, error) {
+	var (
+		id  = cacheID(sendPrivkey, recvPubkey)
+		key ConversationKey
+		ok  bool
+		err error
+	)
+	if key, ok = c.get(id); ok {
+		return key, nil
+	}
+	// generate outside the lock so a miss does not block other lookups
+	if key, err = NewConversationKey(sendPrivkey, recvPubkey); err != nil {
+		return key, err
+	}
+	c.add(id, key)
+	return key, nil
+}
+
+func (c *ConversationKeyCache) get(id [32]byte) (ConversationKey, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.expire(now)
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if c.ttl <= 0 || now.Before(entry.expires) {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			return entry.key, true
+		}
+		c.remove(elem)
+		c.stats.Evictions++
+	}
+	c.stats.Misses++
+	return ConversationKey{}, false
+}
+
+func (c *ConversationKeyCache) add(id [32]byte, key ConversationKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[id]; ok {
+		// another goroutine generated the same key concurrently
+		c.lru.MoveToFront(elem)
+		return
+	}
+	now := c.now()
+	c.expire(now)
+	entry := &cacheEntry{id: id, key: key}
+	if c.ttl > 0 {
+		entry.expires = now.Add(c.ttl)
+	}
+	c.entries[id] = c.lru.PushFront(entry)
+	for c.size > 0 && c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// expire evicts expired keys from the LRU tail so the cache does not grow without a size limit.
+// Recently used keys which expired are evicted when they are looked up or reach the tail.
+func (c *ConversationKeyCache) expire(now time.Time) {
+	for c.ttl > 0 && c.lru.Len() > 0 {
+		elem := c.lru.Back()
+		if now.Before(elem.Value.(*cacheEntry).expires) {
+			return
+		}
+		c.remove(elem)
+		c.stats.Evictions++
+	}
+}
+
+func (c *ConversationKeyCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.id)
+	entry.key.Wipe()
+}
+
+func (c *ConversationKeyCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *ConversationKeyCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Purge wipes and removes all keys.
+func (c *ConversationKeyCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+}
+
+// cacheID identifies a key pair without keeping the private key in memory.
+func cacheID(sendPrivkey []byte, recvPubkey []byte) [32]byte {
+	var id [32]byte
+	h := sha256.New()
+	// length prefix to keep the concatenation unambiguous
+	h.Write([]byte{byte(len(sendPrivkey))})
+	h.Write(sendPrivkey)
+	h.Write(recvPubkey)
+	h.Sum(id[:0])
+	return id
+}
@@ -0,0 +1,175 @@
+package nip44_test
+
+import (
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+func testKeyPair(t testing.TB, sk string) ([]byte, []byte) {
+	var (
+		priv []byte
+		err  error
+	)
+	if priv, err = hex.DecodeString(sk); err != nil {
+		t.Fatalf("hex decode failed for private key: %v", err)
+	}
+	return priv, secp256k1.PrivKeyFromBytes(priv).PubKey().SerializeCompressed()
+}
+
+func TestConversationKeyCache(t *testing.T) {
+	var (
+		sk1, _   = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000001")
+		_, pub2  = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000002")
+		_, pub3  = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000003")
+		expected = "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d"
+		cache    = nip44.NewConversationKeyCache(1, 0)
+		key      nip44.ConversationKey
+		err      error
+	)
+	for i := 0; i < 2; i++ {
+		key, err = cache.Get(sk1, pub2)
+		if assert.NoErrorf(t, err, "conversation key generation failed: %v", err) {
+			assert.Equal(t, expected, hex.EncodeToString(key[:]))
+		}
+	}
+	assert.Equal(t, nip44.CacheStats{Hits: 1, Misses: 1}, cache.Stats())
+	_, err = cache.Get(sk1, pub3)
+	assert.NoErrorf(t, err, "conversation key generation failed: %v", err)
+	assert.Equal(t, nip44.CacheStats{Hits: 1, Misses: 2, Evictions: 1}, cache.Stats())
+	assert.Equal(t, 1, cache.Len())
+	// the returned key is a copy and must not be wiped by the eviction
+	assert.Equal(t, expected, hex.EncodeToString(key[:]))
+	cache.Purge()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestConversationKeyCacheLRU(t *testing.T) {
+	var (
+		sk1, _  = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000001")
+		_, pub2 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000002")
+		_, pub3 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000003")
+		_, pub4 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000004")
+		cache   = nip44.NewConversationKeyCache(2, 0)
+	)
+	cache.Get(sk1, pub2)
+	cache.Get(sk1, pub3)
+	// pub2 is now the most recently used key, so pub3 is evicted
+	cache.Get(sk1, pub2)
+	cache.Get(sk1, pub4)
+	cache.Get(sk1, pub2)
+	assert.Equal(t, nip44.CacheStats{Hits: 2, Misses: 3, Evictions: 1}, cache.Stats())
+	cache.Get(sk1, pub3)
+	assert.Equal(t, nip44.CacheStats{Hits: 2, Misses: 4, Evictions: 2}, cache.Stats())
+}
+
+func TestConversationKeyCacheTTL(t *testing.T) {
+	var (
+		sk1, _  = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000001")
+		_, pub2 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000002")
+		now     = time.Unix(1700000000, 0)
+		cache   = nip44.NewConversationKeyCache(0, time.Minute)
+	)
+	cache.SetNow(func() time.Time { return now })
+	cache.Get(sk1, pub2)
+	now = now.Add(59 * time.Second)
+	cache.Get(sk1, pub2)
+	assert.Equal(t, nip44.CacheStats{Hits: 1, Misses: 1}, cache.Stats())
+	now = now.Add(time.Second)
+	cache.Get(sk1, pub2)
+	assert.Equal(t, nip44.CacheStats{Hits: 1, Misses: 2, Evictions: 1}, cache.Stats())
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestConversationKeyCacheTTLUnbounded(t *testing.T) {
+	var (
+		sk1, _  = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000001")
+		_, pub2 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000002")
+		_, pub3 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000003")
+		_, pub4 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000004")
+		now     = time.Unix(1700000000, 0)
+		cache   = nip44.NewConversationKeyCache(0, time.Minute)
+	)
+	cache.SetNow(func() time.Time { return now })
+	cache.Get(sk1, pub2)
+	now = now.Add(30 * time.Second)
+	cache.Get(sk1, pub3)
+	assert.Equal(t, 2, cache.Len())
+	// expired keys are evicted even if they are never looked up again
+	now = now.Add(30 * time.Second)
+	cache.Get(sk1, pub4)
+	assert.Equal(t, nip44.CacheStats{Misses: 3, Evictions: 1}, cache.Stats())
+	assert.Equal(t, 2, cache.Len())
+	now = now.Add(time.Minute)
+	cache.Get(sk1, pub4)
+	assert.Equal(t, nip44.CacheStats{Misses: 4, Evictions: 3}, cache.Stats())
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestConversationKeyCacheFail(t *testing.T) {
+	var (
+		_, pub2 = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000002")
+		cache   = nip44.NewConversationKeyCache(1, 0)
+		err     error
+	)
+	_, err = cache.Get(make([]byte, 32), pub2)
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestConversationKeyCacheConcurrent(t *testing.T) {
+	var (
+		sk1, _   = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000001")
+		_, pub2  = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000002")
+		_, pub3  = testKeyPair(t, "0000000000000000000000000000000000000000000000000000000000000003")
+		expected = "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d"
+		cache    = nip44.NewConversationKeyCache(1, 0)
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key, err := cache.Get(sk1, pub2)
+				if assert.NoError(t, err) {
+					assert.Equal(t, expected, hex.EncodeToString(key[:]))
+				}
+				cache.Get(sk1, pub3)
+			}
+		}()
+	}
+	wg.Wait()
+	stats := cache.Stats()
+	assert.Equal(t, uint64(1600), stats.Hits+stats.Misses)
+}
+
+func BenchmarkGenerateConversationKey(b *testing.B) {
+	var (
+		sk1, _  = testKeyPair(b, "0000000000000000000000000000000000000000000000000000000000000001")
+		_, pub2 = testKeyPair(b, "0000000000000000000000000000000000000000000000000000000000000002")
+	)
+	for i := 0; i < b.N; i++ {
+		if _, err := nip44.GenerateConversationKey(sk1, pub2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkConversationKeyCache(b *testing.B) {
+	var (
+		sk1, _  = testKeyPair(b, "0000000000000000000000000000000000000000000000000000000000000001")
+		_, pub2 = testKeyPair(b, "0000000000000000000000000000000000000000000000000000000000000002")
+		cache   = nip44.NewConversationKeyCache(100, time.Hour)
+	)
+	for i := 0; i < b.N; i++ {
+		if _, err := cache.Get(sk1, pub2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package nip44
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+	"slices"
+	"sync"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// Cipher encrypts and decrypts version 2 payloads with one conversation key. It reuses its
+// hash state and buffers between calls, so encryption and decryption into a buffer with
+// enough capacity do not allocate. A Cipher is not safe for concurrent use.
+type Cipher struct {
+	key     ConversationKey
+	h       hash.Hash
+	buf     []byte
+	payload Payload
+	salt    [32]byte
+	// HKDF output: chacha key (32), chacha nonce (12), hmac key (32)
+	keys [3 * sha256.Size]byte
+	pad  [sha256.BlockSize]byte
+	ctr  [1]byte
+	sum  [sha256.Size]byte
+	mac  [sha256.Size]byte
+}
+
+var cipherPool = sync.Pool{
+	New: func() any { return &Cipher{h: sha256.New()} },
+}
+
+// NewCipher returns a Cipher for the conversation key. The key is copied.
+func NewCipher(conversationKey []byte) (*Cipher, error) {
+	c := &Cipher{h: sha256.New()}
+	if err := c.Reset(conversationKey); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reset sets a new conversation key, keeping the buffers.
+func (c *Cipher) Reset(conversationKey []byte) error {
+	var err error
+	if c.key, err = ConversationKeyFromBytes(conversationKey); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Wipe overwrites the key and all buffers with zeros. The Cipher can be used again after Reset.
+func (c *Cipher) Wipe() {
+	c.key.Wipe()
+	clear(c.buf[:cap(c.buf)])
+	clear(c.salt[:])
+	clear(c.keys[:])
+	clear(c.pad[:])
+	clear(c.sum[:])
+	clear(c.mac[:])
+	c.h.Reset()
+}
+
+// AppendEncrypt encrypts plaintext and appends the base64 encoded payload to dst.
+// Only version 2 is supported.
+func (c *Cipher) AppendEncrypt(dst []byte, plaintext []byte, options ...Option) ([]byte, error) {
+	if len(options) == 0 {
+		// applying options makes them escape to the heap
+		return c.appendEncrypt(dst, plaintext, EncryptOptions{})
+	}
+	return c.appendEncrypt(dst, plaintext, applyOptions(options))
+}
+
+func (c *Cipher) appendEncrypt(dst []byte, plaintext []byte, o EncryptOptions) ([]byte, error) {
+	var (
+		salt    = c.salt[:]
+		padding int
+		n       int
+		err     error
+	)
+	if o.Version != 0 && o.Version != 2 {
+		return nil, &Error{Err: ErrUnknownVersion, Version: o.Version}
+	}
+	if o.Salt != nil {
+		salt = o.Salt
+	} else {
+		if o.Rand == nil {
+			o.Rand = DefaultRand
+		}
+		if _, err = io.ReadFull(o.Rand, salt); err != nil {
+			return nil, err
+		}
+	}
+	if len(salt) != 32 {
+		return nil, errors.New("salt must be 32 bytes")
+	}
+	if len(plaintext) < MinPlaintextSize || len(plaintext) > MaxPlaintextSize {
+		return nil, ErrInvalidPlaintextLength
+	}
+	c.messageKeys(salt)
+	// version (1) + salt (32) + length prefix (2) + padded plaintext + mac (32)
+	padding = calcPadding(len(plaintext))
+	n = 1 + 32 + 2 + padding + 32
+	// the payload is built in place and the plaintext is XORed with the keystream in place
+	c.buf = slices.Grow(c.buf[:0], n)[:n]
+	c.buf[0] = 2
+	copy(c.buf[1:33], salt)
+	binary.BigEndian.PutUint16(c.buf[33:35], uint16(len(plaintext)))
+	copy(c.buf[35:], plaintext)
+	clear(c.buf[35+len(plaintext) : n-32])
+	ciphertext := c.buf[33 : n-32]
+	c.xorKeyStream(ciphertext)
+	c.hmac(c.buf[n-32:n-32], salt, ciphertext)
+	return appendBase64(dst, c.buf), nil
+}
+
+// AppendDecrypt decrypts the base64 encoded payload and appends the plaintext to dst.
+func (c *Cipher) AppendDecrypt(dst []byte, payload []byte) ([]byte, error) {
+	var (
+		p           = &c.payload
+		version     int
+		unpaddedLen int
+		err         error
+	)
+	if version, err = payloadVersion(payload); err != nil {
+		return nil, err
+	}
+	if version != 2 {
+		return nil, &Error{Err: ErrUnknownVersion, Version: version}
+	}
+	if err = checkPayloadLen(v2{}, payload); err != nil {
+		return nil, err
+	}
+	if c.buf, err = decodePayload(p, c.buf, payload); err != nil {
+		return nil, err
+	}
+	if err = (v2{}).checkPayload(p, payload); err != nil {
+		return nil, err
+	}
+	c.messageKeys(p.Salt)
+	c.hmac(c.mac[:0], p.Salt, p.Ciphertext)
+	// Everything before this point only depends on public data (payload length, encoding,
+	// version and salt). The MAC must be compared in constant time and anything that
+	// depends on secret data, like the padding, is only inspected after authentication.
+	if !hmac.Equal(p.MAC, c.mac[:]) {
+		return nil, ErrInvalidMAC
+	}
+	c.xorKeyStream(p.Ciphertext)
+	defer clear(p.Ciphertext)
+	unpaddedLen = int(binary.BigEndian.Uint16(p.Ciphertext[0:2]))
+	if unpaddedLen < MinPlaintextSize || unpaddedLen > MaxPlaintextSize || len(p.Ciphertext) != 2+calcPadding(unpaddedLen) {
+		return nil, ErrInvalidPadding
+	}
+	return append(dst, p.Ciphertext[2:2+unpaddedLen]...), nil
+}
+
+// messageKeys derives the message keys for the salt with HKDF-Expand into c.keys.
+func (c *Cipher) messageKeys(salt []byte) {
+	// T(i) = HMAC(conversation key, T(i-1) | info | i) with the salt as info
+	for i := 0; i < 3; i++ {
+		c.ctr[0] = byte(i + 1)
+		prev := c.keys[max(0, i-1)*sha256.Size : i*sha256.Size]
+		c.hmacKey(c.keys[i*sha256.Size:i*sha256.Size], c.key[:], prev, salt, c.ctr[:])
+	}
+}
+
+// xorKeyStream encrypts or decrypts b in place.
+func (c *Cipher) xorKeyStream(b []byte) {
+	// key and nonce have the correct sizes so this can not fail
+	cipher, _ := chacha20.NewUnauthenticatedCipher(c.keys[0:32], c.keys[32:44])
+	cipher.XORKeyStream(b, b)
+}
+
+// hmac appends the HMAC-SHA256 of aad and ciphertext with the message auth key to dst.
+func (c *Cipher) hmac(dst []byte, aad []byte, ciphertext []byte) {
+	c.hmacKey(dst, c.keys[44:76], aad, ciphertext, nil)
+}
+
+// hmacKey appends HMAC-SHA256 of the concatenated messages to dst. The key must not be
+// longer than the block size. Unlike crypto/hmac, it does not allocate.
+func (c *Cipher) hmacKey(dst []byte, key []byte, m1 []byte, m2 []byte, m3 []byte) {
+	for i := range c.pad {
+		c.pad[i] = 0x36
+	}
+	for i, b := range key {
+		c.pad[i] ^= b
+	}
+	c.h.Reset()
+	c.h.Write(c.pad[:])
+	c.h.Write(m1)
+	c.h.Write(m2)
+	c.h.Write(m3)
+	c.h.Sum(c.sum[:0])
+	for i := range c.pad {
+		c.pad[i] ^= 0x36 ^ 0x5c
+	}
+	c.h.Reset()
+	c.h.Write(c.pad[:])
+	c.h.Write(c.sum[:])
+	c.h.Sum(dst)
+}
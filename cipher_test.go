@@ -0,0 +1,142 @@
+package nip44_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCipher(t *testing.T) {
+	var (
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		salt, _    = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+		expected   = "AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb"
+		c          *nip44.Cipher
+		payload    []byte
+		plaintext  []byte
+		err        error
+	)
+	if c, err = nip44.NewCipher(convKey); err != nil {
+		t.Fatalf("creating cipher failed: %v", err)
+	}
+	// reuse the cipher to make sure no state leaks between calls
+	for i := 0; i < 2; i++ {
+		payload, err = c.AppendEncrypt(payload[:0], []byte("a"), nip44.WithSalt(salt))
+		if assert.NoErrorf(t, err, "encryption failed: %v", err) {
+			assert.Equal(t, expected, string(payload))
+		}
+		plaintext, err = c.AppendDecrypt(plaintext[:0], payload)
+		if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+			assert.Equal(t, []byte("a"), plaintext)
+		}
+	}
+	_, err = c.AppendDecrypt(nil, []byte(expected[:len(expected)-4]+"AAAA"))
+	assert.ErrorIs(t, err, nip44.ErrInvalidMAC)
+	// versions are rejected the same way as by Encrypt
+	_, err = c.AppendEncrypt(nil, []byte("a"), nip44.WithVersion(2), nip44.WithSalt(salt))
+	assert.NoErrorf(t, err, "encryption failed: %v", err)
+	_, err = c.AppendEncrypt(nil, []byte("a"), nip44.WithVersion(3))
+	assertError(t, &nip44.Error{Err: nip44.ErrUnknownVersion, Version: 3}, err)
+	_, err = nip44.Encrypt(convKey, "a", nip44.WithVersion(3))
+	assertError(t, &nip44.Error{Err: nip44.ErrUnknownVersion, Version: 3}, err)
+	c.Wipe()
+	_, err = nip44.NewCipher(convKey[1:])
+	assert.ErrorIs(t, err, nip44.ErrInvalidConversationKey)
+}
+
+func TestCipherAllocs(t *testing.T) {
+	var (
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		c, _       = nip44.NewCipher(convKey)
+		plaintext  = bytes.Repeat([]byte{'a'}, 1000)
+		payload    = make([]byte, 0, nip44.PayloadLen(len(plaintext)))
+		decrypted  = make([]byte, 0, len(plaintext))
+		err        error
+	)
+	// the first call grows the internal buffer
+	payload, _ = c.AppendEncrypt(payload[:0], plaintext)
+	decrypted, _ = c.AppendDecrypt(decrypted[:0], payload)
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		if payload, err = c.AppendEncrypt(payload[:0], plaintext); err != nil {
+			t.Fatal(err)
+		}
+	}))
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		if decrypted, err = c.AppendDecrypt(decrypted[:0], payload); err != nil {
+			t.Fatal(err)
+		}
+	}))
+}
+
+func benchmarkSizes(b *testing.B, f func(b *testing.B, plaintext []byte)) {
+	for _, size := range []int{32, 1024, 65535} {
+		plaintext := bytes.Repeat([]byte{'a'}, size)
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(size))
+			f(b, plaintext)
+		})
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	convKey, _ := hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+	benchmarkSizes(b, func(b *testing.B, plaintext []byte) {
+		for i := 0; i < b.N; i++ {
+			if _, err := nip44.EncryptBytes(convKey, plaintext); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDecrypt(b *testing.B) {
+	convKey, _ := hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+	benchmarkSizes(b, func(b *testing.B, plaintext []byte) {
+		payload, _ := nip44.EncryptBytes(convKey, plaintext)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := nip44.DecryptBytes(convKey, payload); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCipherEncrypt(b *testing.B) {
+	convKey, _ := hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+	benchmarkSizes(b, func(b *testing.B, plaintext []byte) {
+		var (
+			c, _    = nip44.NewCipher(convKey)
+			payload = make([]byte, 0, nip44.PayloadLen(len(plaintext)))
+			err     error
+		)
+		for i := 0; i < b.N; i++ {
+			if payload, err = c.AppendEncrypt(payload[:0], plaintext); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkCipherDecrypt(b *testing.B) {
+	convKey, _ := hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+	benchmarkSizes(b, func(b *testing.B, plaintext []byte) {
+		var (
+			c, _       = nip44.NewCipher(convKey)
+			payload, _ = c.AppendEncrypt(nil, plaintext)
+			decrypted  = make([]byte, 0, len(plaintext))
+			err        error
+		)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if decrypted, err = c.AppendDecrypt(decrypted[:0], payload); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,302 @@
+// Command nip44 encrypts and decrypts NIP-44 payloads and derives the keys involved.
+//
+// Keys can be given as hex or as nsec, npub or nprofile. To avoid leaking secrets via the process list,
+// a key argument starting with @ is read from the named file instead.
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nip19"
+)
+
+const usage = `usage: nip44 <command> [flags] [args]
+
+commands:
+  encrypt           encrypt plaintext from stdin
+  decrypt           decrypt payload from argument or stdin
+  conversation-key  derive the conversation key of two parties
+  message-keys      derive the message keys for a salt
+  inspect           show the structure of a payload without decrypting it
+  padded-len        show padded and payload length for plaintext lengths
+
+Run 'nip44 <command> -h' for the flags of a command.
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) && !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "nip44: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		cmd func([]string, io.Reader, io.Writer) error
+		ok  bool
+	)
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errUsage
+	}
+	if cmd, ok = map[string]func([]string, io.Reader, io.Writer) error{
+		"encrypt":          encrypt,
+		"decrypt":          decrypt,
+		"conversation-key": conversationKey,
+		"message-keys":     messageKeys,
+		"inspect":          inspect,
+		"padded-len":       paddedLen,
+	}[args[0]]; !ok {
+		fmt.Fprintf(os.Stderr, "nip44: unknown command %q\n\n%s", args[0], usage)
+		return errUsage
+	}
+	return cmd(args[1:], stdin, stdout)
+}
+
+// keyFlags are the flags of commands which need a conversation key.
+type keyFlags struct {
+	sec string
+	pub string
+	key string
+}
+
+func (k *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&k.sec, "sec", "", "our private key (hex, nsec or @file)")
+	fs.StringVar(&k.pub, "pub", "", "their public key (hex, npub, nprofile or @file)")
+	fs.StringVar(&k.key, "key", "", "conversation key (hex or @file), instead of -sec and -pub")
+}
+
+func (k *keyFlags) conversationKey() (nip44.ConversationKey, error) {
+	var (
+		key nip44.ConversationKey
+		sec []byte
+		pub []byte
+		s   string
+		err error
+	)
+	if k.key != "" {
+		if s, err = readArg(k.key); err != nil {
+			return key, err
+		}
+		return nip44.ConversationKeyFromHex(s)
+	}
+	if k.sec == "" || k.pub == "" {
+		return key, errors.New("either -key or -sec and -pub are required")
+	}
+	if s, err = readArg(k.sec); err != nil {
+		return key, fmt.Errorf("-sec: %w", err)
+	}
+	if sec, err = nip19.ParsePrivateKey(s); err != nil {
+		return key, fmt.Errorf("-sec: %w", err)
+	}
+	defer clear(sec)
+	if s, err = readArg(k.pub); err != nil {
+		return key, fmt.Errorf("-pub: %w", err)
+	}
+	if pub, err = nip19.ParsePublicKey(s); err != nil {
+		return key, fmt.Errorf("-pub: %w", err)
+	}
+	return nip44.NewConversationKey(sec, pub)
+}
+
+// readArg returns the argument or the trimmed contents of the file if the argument starts with @.
+func readArg(arg string) (string, error) {
+	if !strings.HasPrefix(arg, "@") {
+		return arg, nil
+	}
+	b, err := os.ReadFile(arg[1:])
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// readPayload returns the payload from the arguments or stdin.
+func readPayload(fs *flag.FlagSet, stdin io.Reader) (string, error) {
+	if fs.NArg() > 1 {
+		return "", errors.New("too many arguments")
+	}
+	if fs.NArg() == 1 {
+		return fs.Arg(0), nil
+	}
+	b, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func encrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs        = flag.NewFlagSet("encrypt", flag.ContinueOnError)
+		k         keyFlags
+		saltHex   = fs.String("salt", "", "salt as hex, random if empty")
+		key       nip44.ConversationKey
+		salt      []byte
+		plaintext []byte
+		payload   string
+		err       error
+	)
+	k.register(fs)
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	if key, err = k.conversationKey(); err != nil {
+		return err
+	}
+	defer key.Wipe()
+	if *saltHex != "" {
+		if salt, err = hex.DecodeString(*saltHex); err != nil {
+			return fmt.Errorf("-salt: %w", err)
+		}
+	}
+	if plaintext, err = io.ReadAll(stdin); err != nil {
+		return err
+	}
+	if payload, err = key.Encrypt(string(plaintext), nip44.WithSalt(salt)); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, payload)
+	return err
+}
+
+func decrypt(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs        = flag.NewFlagSet("decrypt", flag.ContinueOnError)
+		k         keyFlags
+		key       nip44.ConversationKey
+		payload   string
+		plaintext string
+		err       error
+	)
+	k.register(fs)
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	if key, err = k.conversationKey(); err != nil {
+		return err
+	}
+	defer key.Wipe()
+	if payload, err = readPayload(fs, stdin); err != nil {
+		return err
+	}
+	if plaintext, err = key.Decrypt(payload); err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, plaintext)
+	return err
+}
+
+func conversationKey(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs  = flag.NewFlagSet("conversation-key", flag.ContinueOnError)
+		k   keyFlags
+		key nip44.ConversationKey
+		err error
+	)
+	fs.StringVar(&k.sec, "sec", "", "our private key (hex, nsec or @file)")
+	fs.StringVar(&k.pub, "pub", "", "their public key (hex, npub, nprofile or @file)")
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	if key, err = k.conversationKey(); err != nil {
+		return err
+	}
+	defer key.Wipe()
+	_, err = fmt.Fprintln(stdout, hex.EncodeToString(key[:]))
+	return err
+}
+
+func messageKeys(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs         = flag.NewFlagSet("message-keys", flag.ContinueOnError)
+		k          keyFlags
+		saltHex    = fs.String("salt", "", "salt as hex")
+		key        nip44.ConversationKey
+		salt       []byte
+		enc, nonce []byte
+		auth       []byte
+		err        error
+	)
+	k.register(fs)
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	if key, err = k.conversationKey(); err != nil {
+		return err
+	}
+	defer key.Wipe()
+	if salt, err = hex.DecodeString(*saltHex); err != nil {
+		return fmt.Errorf("-salt: %w", err)
+	}
+	if enc, nonce, auth, err = key.MessageKeys(salt); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "chacha key: %x\nchacha nonce: %x\nhmac key: %x\n", enc, nonce, auth)
+	return err
+}
+
+func inspect(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs      = flag.NewFlagSet("inspect", flag.ContinueOnError)
+		payload string
+		p       *nip44.Payload
+		err     error
+	)
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	if payload, err = readPayload(fs, stdin); err != nil {
+		return err
+	}
+	if p, err = nip44.ParsePayload(payload); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "version: %d\nsalt: %x\nciphertext length: %d\nmac: %x\n",
+		p.Version, p.Salt, len(p.Ciphertext), p.MAC)
+	return err
+}
+
+func paddedLen(args []string, stdin io.Reader, stdout io.Writer) error {
+	var (
+		fs     = flag.NewFlagSet("padded-len", flag.ContinueOnError)
+		n      int
+		padded int
+		err    error
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: nip44 padded-len <plaintext length>...")
+		fmt.Fprintln(fs.Output(), "prints plaintext length, padded length and payload length per line")
+	}
+	if err = fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+	for _, arg := range fs.Args() {
+		if n, err = strconv.Atoi(arg); err != nil {
+			return err
+		}
+		if padded, err = nip44.PaddedLen(n); err != nil {
+			return fmt.Errorf("%d: %w", n, err)
+		}
+		if _, err = fmt.Fprintf(stdout, "%d\t%d\t%d\n", n, padded, nip44.PayloadLen(n)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertRun(t *testing.T, args []string, stdin string, expected string) bool {
+	var (
+		stdout bytes.Buffer
+		err    error
+	)
+	err = run(args, strings.NewReader(stdin), &stdout)
+	if ok := assert.NoErrorf(t, err, "%s failed: %v", args[0], err); !ok {
+		return false
+	}
+	return assert.Equal(t, expected, stdout.String())
+}
+
+func TestConversationKey(t *testing.T) {
+	assertRun(t, []string{"conversation-key",
+		"-sec", "0000000000000000000000000000000000000000000000000000000000000001",
+		"-pub", "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+	}, "", "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d\n")
+}
+
+func TestConversationKeyBech32(t *testing.T) {
+	var (
+		hexKey    bytes.Buffer
+		bech32Key bytes.Buffer
+		fileKey   bytes.Buffer
+		path      = filepath.Join(t.TempDir(), "nsec")
+		err       error
+	)
+	err = run([]string{"conversation-key",
+		"-sec", "67dea2ed018072d675f5415ecfaed7d2597555e202d85b3d65ea4e58d2d92ffa",
+		"-pub", "7e7e9c42a91bfef19fa929e5fda1b72e0ebc1a4c1141673e2794234d86addf4e",
+	}, nil, &hexKey)
+	if ok := assert.NoErrorf(t, err, "conversation-key failed: %v", err); !ok {
+		return
+	}
+	err = run([]string{"conversation-key",
+		"-sec", "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5",
+		"-pub", "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+	}, nil, &bech32Key)
+	if ok := assert.NoErrorf(t, err, "conversation-key failed: %v", err); !ok {
+		return
+	}
+	assert.Equal(t, hexKey.String(), bech32Key.String())
+	err = os.WriteFile(path, []byte("nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5\n"), 0600)
+	if ok := assert.NoErrorf(t, err, "writing key file failed: %v", err); !ok {
+		return
+	}
+	err = run([]string{"conversation-key",
+		"-sec", "@" + path,
+		"-pub", "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+	}, nil, &fileKey)
+	if ok := assert.NoErrorf(t, err, "conversation-key failed: %v", err); !ok {
+		return
+	}
+	assert.Equal(t, hexKey.String(), fileKey.String())
+	// npub passed as private key
+	err = run([]string{"conversation-key",
+		"-sec", "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+		"-pub", "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg",
+	}, nil, &fileKey)
+	assert.Error(t, err)
+}
+
+func TestCrypt(t *testing.T) {
+	var (
+		key     = "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d"
+		salt    = "0000000000000000000000000000000000000000000000000000000000000001"
+		payload = "AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb"
+	)
+	assertRun(t, []string{"encrypt", "-key", key, "-salt", salt}, "a", payload+"\n")
+	assertRun(t, []string{"encrypt",
+		"-sec", "0000000000000000000000000000000000000000000000000000000000000001",
+		"-pub", "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+		"-salt", salt,
+	}, "a", payload+"\n")
+	assertRun(t, []string{"decrypt", "-key", key, payload}, "", "a")
+	assertRun(t, []string{"decrypt", "-key", key}, payload+"\n", "a")
+}
+
+func TestMessageKeys(t *testing.T) {
+	assertRun(t, []string{"message-keys",
+		"-key", "a1a3d60f3470a8612633924e91febf96dc5366ce130f658b1f0fc652c20b3b54",
+		"-salt", "e1e6f880560d6d149ed83dcc7e5861ee62a5ee051f7fde9975fe5d25d2a02d72",
+	}, "", "chacha key: f145f3bed47cb70dbeaac07f3a3fe683e822b3715edb7c4fe310829014ce7d76\n"+
+		"chacha nonce: c4ad129bb01180c0933a160c\n"+
+		"hmac key: 027c1db445f05e2eee864a0975b0ddef5b7110583c8c192de3732571ca5838c4\n")
+}
+
+func TestInspect(t *testing.T) {
+	assertRun(t, []string{"inspect",
+		"AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb",
+	}, "", "version: 2\n"+
+		"salt: 0000000000000000000000000000000000000000000000000000000000000001\n"+
+		"ciphertext length: 34\n"+
+		"mac: 794259929a02bb06ad8e8cf709ee4ccc567e9d514cdf5781af27a3e905e55b1b\n")
+}
+
+func TestPaddedLen(t *testing.T) {
+	assertRun(t, []string{"padded-len", "1", "33", "65535"}, "", "1\t32\t132\n33\t64\t176\n65535\t65536\t87472\n")
+}
@@ -0,0 +1,53 @@
+package compat
+
+import (
+	"strings"
+
+	"github.com/ekzyis/nip44"
+)
+
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	FormatNIP04
+	FormatNIP44
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatNIP04:
+		return "nip04"
+	case FormatNIP44:
+		return "nip44"
+	}
+	return "unknown"
+}
+
+// Detect returns the encryption format of the content without decrypting it.
+func Detect(content string) Format {
+	if strings.Contains(content, ivSeparator) {
+		return FormatNIP04
+	}
+	if _, err := nip44.ParsePayload(content); err == nil {
+		return FormatNIP44
+	}
+	return FormatUnknown
+}
+
+// DecryptAny decrypts NIP-04 or NIP-44 content, depending on its format.
+func DecryptAny(privkey []byte, pubkey []byte, content string) (string, error) {
+	var (
+		key []byte
+		err error
+	)
+	if Detect(content) == FormatNIP04 {
+		return NIP04Decrypt(privkey, pubkey, content)
+	}
+	// anything else is passed to nip44 to get its error
+	if key, err = nip44.GenerateConversationKey(privkey, pubkey); err != nil {
+		return "", err
+	}
+	defer clear(key)
+	return nip44.Decrypt(key, content)
+}
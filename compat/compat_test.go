@@ -0,0 +1,92 @@
+package compat_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/compat"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	sk1, _  = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	sk2, _  = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000002")
+	pub1, _ = hex.DecodeString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	pub2, _ = hex.DecodeString("c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5")
+)
+
+func TestNIP04(t *testing.T) {
+	var (
+		iv, _ = hex.DecodeString("0102030405060708090a0b0c0d0e0f10")
+		// created with openssl enc -aes-256-cbc, the shared secret of sk1 and pub2 is the x coordinate of pub2
+		vectors = []struct {
+			iv        []byte
+			plaintext string
+			content   string
+		}{
+			{make([]byte, 16), "a", "sfwxq7QUUWEOFgVTi+sy6w==?iv=AAAAAAAAAAAAAAAAAAAAAA=="},
+			{iv, "hello world, this is longer than one block", "wJazkoBm0631luJ1vN8OvCSwjGx+YznausL2mLYW5Ep5eTyibvTM+ok9ZlP53vJ0?iv=AQIDBAUGBwgJCgsMDQ4PEA=="},
+		}
+	)
+	for _, v := range vectors {
+		actual, err := compat.NIP04EncryptWithRand(bytes.NewReader(v.iv), sk1, pub2, v.plaintext)
+		if assert.NoErrorf(t, err, "encryption failed: %v", err) {
+			assert.Equal(t, v.content, actual)
+		}
+		plaintext, err := compat.NIP04Decrypt(sk2, pub1, v.content)
+		if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+			assert.Equal(t, v.plaintext, plaintext)
+		}
+	}
+}
+
+func TestNIP04Roundtrip(t *testing.T) {
+	for _, plaintext := range []string{"", "0123456789abcdef", "ünïcödé"} {
+		content, err := compat.NIP04Encrypt(sk1, pub2, plaintext)
+		if ok := assert.NoErrorf(t, err, "encryption failed: %v", err); !ok {
+			continue
+		}
+		actual, err := compat.NIP04Decrypt(sk2, pub1, content)
+		if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+			assert.Equal(t, plaintext, actual)
+		}
+	}
+}
+
+func TestNIP04DecryptFail(t *testing.T) {
+	for content, expected := range map[string]error{
+		"sfwxq7QUUWEOFgVTi+sy6w==":                             compat.ErrInvalidContent,
+		"sfwxq7QUUWEOFgVTi+sy6w==?iv=AAAA":                     compat.ErrInvalidContent,
+		"sfwxq7QUUWEOFgVTi+sy?iv=AAAAAAAAAAAAAAAAAAAAAA==":     compat.ErrInvalidContent,
+		"!?iv=AAAAAAAAAAAAAAAAAAAAAA==":                        compat.ErrInvalidContent,
+		"sfwxq7QUUWEOFgVTi+sy6w==?iv=AAAAAAAAAAAAAAAAAAAAAQ==": compat.ErrInvalidPadding,
+	} {
+		_, err := compat.NIP04Decrypt(sk2, pub1, content)
+		assert.ErrorIsf(t, err, expected, "content %s", content)
+	}
+	_, err := compat.NIP04Decrypt(make([]byte, 32), pub1, "sfwxq7QUUWEOFgVTi+sy6w==?iv=AAAAAAAAAAAAAAAAAAAAAA==")
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+}
+
+func TestDetect(t *testing.T) {
+	assert.Equal(t, compat.FormatNIP04, compat.Detect("sfwxq7QUUWEOFgVTi+sy6w==?iv=AAAAAAAAAAAAAAAAAAAAAA=="))
+	assert.Equal(t, compat.FormatNIP44, compat.Detect("AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb"))
+	assert.Equal(t, compat.FormatUnknown, compat.Detect("hello"))
+	assert.Equal(t, "nip04", compat.FormatNIP04.String())
+}
+
+func TestDecryptAny(t *testing.T) {
+	for content, expected := range map[string]string{
+		"sfwxq7QUUWEOFgVTi+sy6w==?iv=AAAAAAAAAAAAAAAAAAAAAA==":                                                                                 "a",
+		"AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb": "a",
+	} {
+		actual, err := compat.DecryptAny(sk2, pub1, content)
+		if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+			assert.Equal(t, expected, actual)
+		}
+	}
+	_, err := compat.DecryptAny(sk2, pub1, "hello")
+	assert.ErrorIs(t, err, nip44.ErrInvalidPayloadLength)
+}
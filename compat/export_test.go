@@ -0,0 +1,3 @@
+package compat
+
+var NIP04EncryptWithRand = nip04Encrypt
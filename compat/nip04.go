@@ -0,0 +1,117 @@
+// Package compat supports the legacy NIP-04 encryption next to NIP-44 so clients can read
+// old direct messages while migrating.
+//
+// NIP-04 is deprecated: it is unauthenticated and leaks the message length.
+// See https://github.com/nostr-protocol/nips/blob/master/04.md
+package compat
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/ekzyis/nip44"
+)
+
+const ivSeparator = "?iv="
+
+var (
+	ErrInvalidContent = errors.New("invalid nip04 content")
+	ErrInvalidPadding = errors.New("invalid padding")
+)
+
+// NIP04Encrypt encrypts the plaintext with AES-256-CBC using the unhashed ECDH shared secret as key.
+// Public keys can be x-only, compressed or uncompressed.
+func NIP04Encrypt(privkey []byte, pubkey []byte, plaintext string) (string, error) {
+	return nip04Encrypt(rand.Reader, privkey, pubkey, plaintext)
+}
+
+func nip04Encrypt(r io.Reader, privkey []byte, pubkey []byte, plaintext string) (string, error) {
+	var (
+		key        []byte
+		block      cipher.Block
+		iv         = make([]byte, aes.BlockSize)
+		padding    = aes.BlockSize - len(plaintext)%aes.BlockSize
+		ciphertext []byte
+		err        error
+	)
+	if key, err = sharedSecret(privkey, pubkey); err != nil {
+		return "", err
+	}
+	defer clear(key)
+	if _, err = io.ReadFull(r, iv); err != nil {
+		return "", err
+	}
+	block, _ = aes.NewCipher(key)
+	// PKCS#7 padding
+	ciphertext = append([]byte(plaintext), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+	return base64.StdEncoding.EncodeToString(ciphertext) + ivSeparator + base64.StdEncoding.EncodeToString(iv), nil
+}
+
+// NIP04Decrypt decrypts content of the form base64(ciphertext)?iv=base64(iv).
+func NIP04Decrypt(privkey []byte, pubkey []byte, content string) (string, error) {
+	var (
+		key        []byte
+		block      cipher.Block
+		ciphertext []byte
+		iv         []byte
+		err        error
+	)
+	encoded, encodedIV, ok := strings.Cut(content, ivSeparator)
+	if !ok {
+		return "", ErrInvalidContent
+	}
+	if ciphertext, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+		return "", ErrInvalidContent
+	}
+	if iv, err = base64.StdEncoding.DecodeString(encodedIV); err != nil {
+		return "", ErrInvalidContent
+	}
+	if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return "", ErrInvalidContent
+	}
+	if key, err = sharedSecret(privkey, pubkey); err != nil {
+		return "", err
+	}
+	defer clear(key)
+	block, _ = aes.NewCipher(key)
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+	padding := int(ciphertext[len(ciphertext)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return "", ErrInvalidPadding
+	}
+	for _, b := range ciphertext[len(ciphertext)-padding:] {
+		if int(b) != padding {
+			return "", ErrInvalidPadding
+		}
+	}
+	return string(ciphertext[:len(ciphertext)-padding]), nil
+}
+
+// sharedSecret returns the x coordinate of the ECDH point. Unlike NIP-44, NIP-04 does not hash it.
+func sharedSecret(privkey []byte, pubkey []byte) ([]byte, error) {
+	var (
+		s   secp256k1.ModNScalar
+		pk  *secp256k1.PublicKey
+		err error
+	)
+	if len(privkey) != 32 || s.SetByteSlice(privkey) || s.IsZero() {
+		return nil, nip44.ErrInvalidPrivateKey
+	}
+	sk := secp256k1.NewPrivateKey(&s)
+	defer sk.Zero()
+	if len(pubkey) == 32 {
+		pubkey = append([]byte{secp256k1.PubKeyFormatCompressedEven}, pubkey...)
+	}
+	if pk, err = secp256k1.ParsePubKey(pubkey); err != nil {
+		return nil, &nip44.Error{Err: nip44.ErrInvalidPublicKey, Cause: err}
+	}
+	return secp256k1.GenerateSharedSecret(sk, pk), nil
+}
@@ -0,0 +1,63 @@
+package nip44
+
+import (
+	"encoding/hex"
+)
+
+// ConversationKey is the shared secret between two parties from which the message keys
+// for each payload are derived.
+type ConversationKey [32]byte
+
+func NewConversationKey(sendPrivkey []byte, recvPubkey []byte) (ConversationKey, error) {
+	var (
+		key ConversationKey
+		b   []byte
+		err error
+	)
+	if b, err = GenerateConversationKey(sendPrivkey, recvPubkey); err != nil {
+		return key, err
+	}
+	copy(key[:], b)
+	clear(b)
+	return key, nil
+}
+
+func ConversationKeyFromBytes(b []byte) (ConversationKey, error) {
+	var key ConversationKey
+	if len(b) != len(key) {
+		return key, ErrInvalidConversationKey
+	}
+	copy(key[:], b)
+	return key, nil
+}
+
+func ConversationKeyFromHex(s string) (ConversationKey, error) {
+	var (
+		key ConversationKey
+		b   []byte
+		err error
+	)
+	if b, err = hex.DecodeString(s); err != nil {
+		return key, &Error{Err: ErrInvalidConversationKey, Cause: err}
+	}
+	defer clear(b)
+	return ConversationKeyFromBytes(b)
+}
+
+func (k *ConversationKey) Encrypt(plaintext string, options ...Option) (string, error) {
+	return Encrypt(k[:], plaintext, options...)
+}
+
+func (k *ConversationKey) Decrypt(payload string) (string, error) {
+	return Decrypt(k[:], payload)
+}
+
+// MessageKeys returns the ChaCha20 key, ChaCha20 nonce and HMAC key for the given salt.
+func (k *ConversationKey) MessageKeys(salt []byte) ([]byte, []byte, []byte, error) {
+	return messageKeys(k[:], salt)
+}
+
+// Wipe overwrites the key with zeros. The key must not be used afterwards.
+func (k *ConversationKey) Wipe() {
+	clear(k[:])
+}
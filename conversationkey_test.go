@@ -0,0 +1,84 @@
+package nip44_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversationKeyFromKeys(t *testing.T) {
+	var (
+		sk1, _   = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+		pub2, _  = hex.DecodeString("02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5")
+		expected = "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d"
+		key      nip44.ConversationKey
+		err      error
+	)
+	key, err = nip44.NewConversationKey(sk1, pub2)
+	if assert.NoErrorf(t, err, "conversation key generation failed: %v", err) {
+		assert.Equal(t, expected, hex.EncodeToString(key[:]))
+	}
+	_, err = nip44.NewConversationKey(make([]byte, 32), pub2)
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+}
+
+func TestConversationKeyFromHex(t *testing.T) {
+	var (
+		key nip44.ConversationKey
+		err error
+	)
+	key, err = nip44.ConversationKeyFromHex("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+	if assert.NoErrorf(t, err, "hex decode failed for conversation key: %v", err) {
+		assert.Equal(t, byte(0xc4), key[0])
+		assert.Equal(t, byte(0x2d), key[31])
+	}
+	_, err = nip44.ConversationKeyFromHex("c41c")
+	assert.ErrorIs(t, err, nip44.ErrInvalidConversationKey)
+	_, err = nip44.ConversationKeyFromHex("zz")
+	assert.ErrorIs(t, err, nip44.ErrInvalidConversationKey)
+	_, err = nip44.ConversationKeyFromBytes(make([]byte, 33))
+	assert.ErrorIs(t, err, nip44.ErrInvalidConversationKey)
+}
+
+func TestConversationKeyCrypt(t *testing.T) {
+	var (
+		key, _    = nip44.ConversationKeyFromHex("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		salt, _   = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+		expected  = "AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb"
+		payload   string
+		plaintext string
+		err       error
+	)
+	payload, err = key.Encrypt("a", &nip44.EncryptOptions{Salt: salt})
+	if assert.NoErrorf(t, err, "encryption failed: %v", err) {
+		assert.Equal(t, expected, payload, "wrong encryption")
+	}
+	plaintext, err = key.Decrypt(expected)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, "a", plaintext, "wrong decryption")
+	}
+}
+
+func TestConversationKeyMessageKeys(t *testing.T) {
+	var (
+		key, _     = nip44.ConversationKeyFromHex("a1a3d60f3470a8612633924e91febf96dc5366ce130f658b1f0fc652c20b3b54")
+		salt, _    = hex.DecodeString("e1e6f880560d6d149ed83dcc7e5861ee62a5ee051f7fde9975fe5d25d2a02d72")
+		enc, nonce []byte
+		auth       []byte
+		err        error
+	)
+	enc, nonce, auth, err = key.MessageKeys(salt)
+	if assert.NoErrorf(t, err, "message key generation failed: %v", err) {
+		assert.Equal(t, "f145f3bed47cb70dbeaac07f3a3fe683e822b3715edb7c4fe310829014ce7d76", hex.EncodeToString(enc))
+		assert.Equal(t, "c4ad129bb01180c0933a160c", hex.EncodeToString(nonce))
+		assert.Equal(t, "027c1db445f05e2eee864a0975b0ddef5b7110583c8c192de3732571ca5838c4", hex.EncodeToString(auth))
+	}
+}
+
+func TestConversationKeyWipe(t *testing.T) {
+	key, _ := nip44.ConversationKeyFromHex("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+	key.Wipe()
+	assert.Equal(t, nip44.ConversationKey{}, key)
+}
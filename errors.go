@@ -0,0 +1,58 @@
+package nip44
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidMAC           = errors.New("invalid hmac")
+	ErrInvalidPadding       = errors.New("invalid padding")
+	ErrUnknownVersion       = errors.New("unknown version")
+	ErrInvalidPayloadLength = errors.New("invalid payload length")
+	ErrInvalidBase64        = errors.New("invalid base64")
+	ErrInvalidPrivateKey    = errors.New("invalid private key")
+	ErrInvalidPublicKey     = errors.New("invalid public key")
+
+	ErrInvalidConversationKey = errors.New("conversation key must be 32 bytes")
+	ErrInvalidPlaintextLength = errors.New("plaintext should be between 1b and 64kB")
+
+	ErrStreamTruncated = errors.New("stream truncated")
+	ErrStreamCorrupted = errors.New("stream chunk out of order or from another stream")
+	ErrStreamClosed    = errors.New("write to closed stream")
+
+	// ErrSaltNotAllowed is returned by batches and streams which encrypt many messages
+	// since the same salt must never be used twice.
+	ErrSaltNotAllowed = errors.New("salt can not be set for multiple messages")
+)
+
+// Error carries details about a failure. Err is always one of the sentinel errors above
+// so callers can match with errors.Is and inspect the details with errors.As.
+type Error struct {
+	Err     error
+	Version int   // offending version if Err is ErrUnknownVersion
+	Length  int   // offending payload length if Err is ErrInvalidPayloadLength
+	Cause   error // underlying error, if any
+}
+
+func (e *Error) Error() string {
+	switch e.Err {
+	case ErrUnknownVersion:
+		return fmt.Sprintf("%s %d", e.Err, e.Version)
+	case ErrInvalidPayloadLength:
+		return fmt.Sprintf("%s: %d", e.Err, e.Length)
+	}
+	if e.Cause != nil {
+		// secp256k1 errors already start with "invalid public key: "
+		return fmt.Sprintf("%s: %s", e.Err, strings.TrimPrefix(e.Cause.Error(), e.Err.Error()+": "))
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Err, e.Cause}
+	}
+	return []error{e.Err}
+}
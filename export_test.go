@@ -1,4 +1,19 @@
 package nip44
 
+import "time"
+
 // https://stackoverflow.com/a/60813569
 var MessageKeys = messageKeys
+var CalcPadding = calcPadding
+
+func (c *ConversationKeyCache) SetNow(now func() time.Time) {
+	c.now = now
+}
+
+func UnregisterScheme(version int) {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	delete(schemes, version)
+}
+
+const StreamChunkSize = streamChunkSize
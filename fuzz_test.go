@@ -0,0 +1,154 @@
+package nip44_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"golang.org/x/crypto/chacha20"
+)
+
+// The seed corpus in testdata/fuzz is generated from testdata/nip44.vectors.json.
+// Other files in testdata/fuzz are failing inputs found by fuzzing and are kept as regression seeds.
+//go:generate go run gen_fuzz_corpus.go
+
+func FuzzDecrypt(f *testing.F) {
+	f.Fuzz(func(t *testing.T, conversationKey []byte, payload string) {
+		// must not panic, errors are expected
+		plaintext, err := nip44.Decrypt(conversationKey, payload)
+		if err != nil {
+			return
+		}
+		if len(plaintext) < nip44.MinPlaintextSize || len(plaintext) > nip44.MaxPlaintextSize {
+			t.Fatalf("decrypted plaintext has invalid length %d", len(plaintext))
+		}
+	})
+}
+
+func FuzzEncryptDecrypt(f *testing.F) {
+	f.Fuzz(func(t *testing.T, conversationKey []byte, salt []byte, plaintext string) {
+		payload, err := nip44.Encrypt(conversationKey, plaintext, nip44.WithSalt(salt))
+		if err != nil {
+			if len(conversationKey) == 32 && len(salt) == 32 &&
+				len(plaintext) >= nip44.MinPlaintextSize && len(plaintext) <= nip44.MaxPlaintextSize {
+				t.Fatalf("encryption failed: %v", err)
+			}
+			return
+		}
+		if len(payload) != nip44.PayloadLen(len(plaintext)) {
+			t.Fatalf("payload has length %d, expected %d", len(payload), nip44.PayloadLen(len(plaintext)))
+		}
+		decrypted, err := nip44.Decrypt(conversationKey, payload)
+		if err != nil {
+			t.Fatalf("decryption failed: %v", err)
+		}
+		if decrypted != plaintext {
+			t.Fatalf("decrypted %q, expected %q", decrypted, plaintext)
+		}
+	})
+}
+
+func FuzzPad(f *testing.F) {
+	var (
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		salt, _    = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	)
+	f.Fuzz(func(t *testing.T, plaintext []byte) {
+		payload, err := nip44.EncryptBytes(convKey, plaintext, nip44.WithSalt(salt))
+		if len(plaintext) < nip44.MinPlaintextSize || len(plaintext) > nip44.MaxPlaintextSize {
+			if !errors.Is(err, nip44.ErrInvalidPlaintextLength) {
+				t.Fatalf("expected %v, got %v", nip44.ErrInvalidPlaintextLength, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("encryption failed: %v", err)
+		}
+		p, err := nip44.ParsePayload(string(payload))
+		if err != nil {
+			t.Fatalf("parsing payload failed: %v", err)
+		}
+		// the ciphertext is the length prefix and the padded plaintext
+		if padded, _ := nip44.PaddedLen(len(plaintext)); len(p.Ciphertext) != 2+padded {
+			t.Fatalf("padded length is %d, expected %d", len(p.Ciphertext)-2, padded)
+		}
+		decrypted, err := nip44.DecryptBytes(convKey, payload)
+		if err != nil {
+			t.Fatalf("decryption failed: %v", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Fatalf("decrypted %x, expected %x", decrypted, plaintext)
+		}
+	})
+}
+
+func FuzzUnpad(f *testing.F) {
+	var (
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		salt, _    = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	)
+	f.Fuzz(func(t *testing.T, padded []byte) {
+		// authenticate arbitrary padded plaintexts so decryption reaches the padding check
+		enc, nonce, auth, err := nip44.MessageKeys(convKey, salt)
+		if err != nil {
+			t.Fatalf("message key generation failed: %v", err)
+		}
+		cipher, _ := chacha20.NewUnauthenticatedCipher(enc, nonce)
+		ciphertext := make([]byte, len(padded))
+		cipher.XORKeyStream(ciphertext, padded)
+		mac := hmac.New(sha256.New, auth)
+		mac.Write(salt)
+		mac.Write(ciphertext)
+		p := &nip44.Payload{Version: 2, Salt: salt, Ciphertext: ciphertext, MAC: mac.Sum(nil)}
+
+		plaintext, err := nip44.Decrypt(convKey, p.Encode())
+		if err != nil {
+			if !errors.Is(err, nip44.ErrInvalidPadding) && !errors.Is(err, nip44.ErrInvalidPayloadLength) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return
+		}
+		// the padding bytes are not checked, only the length prefix, the plaintext and the length
+		n := int(binary.BigEndian.Uint16(padded))
+		if expected, _ := nip44.PaddedLen(n); len(padded) != 2+expected || plaintext != string(padded[2:2+n]) {
+			t.Fatalf("decrypted %x from %x", plaintext, padded)
+		}
+	})
+}
+
+func FuzzParsePayload(f *testing.F) {
+	f.Fuzz(func(t *testing.T, payload string) {
+		p, err := nip44.ParsePayload(payload)
+		if err != nil {
+			return
+		}
+		if len(p.Salt) != 32 || len(p.MAC) != 32 {
+			t.Fatalf("parsed salt and mac have lengths %d and %d", len(p.Salt), len(p.MAC))
+		}
+		// base64 decoding is lenient with newlines, so compare the parsed payloads
+		q, err := nip44.ParsePayload(p.Encode())
+		if err != nil {
+			t.Fatalf("parsing encoded payload failed: %v", err)
+		}
+		if !bytes.Equal(q.Salt, p.Salt) || !bytes.Equal(q.Ciphertext, p.Ciphertext) || !bytes.Equal(q.MAC, p.MAC) {
+			t.Fatalf("encoded payload does not round trip")
+		}
+	})
+}
+
+func FuzzGenerateConversationKey(f *testing.F) {
+	f.Fuzz(func(t *testing.T, privkey []byte, pubkey []byte) {
+		conversationKey, err := nip44.GenerateConversationKey(privkey, pubkey)
+		if err != nil {
+			return
+		}
+		if len(conversationKey) != 32 {
+			t.Fatalf("conversation key has length %d", len(conversationKey))
+		}
+	})
+}
@@ -0,0 +1,153 @@
+//go:build ignore
+
+// This program generates the seed corpus in testdata/fuzz from testdata/nip44.vectors.json.
+// Run it with go generate.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/bits"
+	"os"
+	"path/filepath"
+)
+
+const (
+	vectorsPath = "testdata/nip44.vectors.json"
+	corpusPath  = "testdata/fuzz"
+	// longer plaintexts of calc_padded_len would only bloat the corpus
+	maxPadSeedLen = 1024
+)
+
+type vectors struct {
+	V2 struct {
+		Valid struct {
+			GetConversationKey []struct {
+				Sec1 string `json:"sec1"`
+				Pub2 string `json:"pub2"`
+			} `json:"get_conversation_key"`
+			CalcPaddedLen  [][2]int `json:"calc_padded_len"`
+			EncryptDecrypt []struct {
+				ConversationKey string `json:"conversation_key"`
+				Nonce           string `json:"nonce"`
+				Plaintext       string `json:"plaintext"`
+				Payload         string `json:"payload"`
+			} `json:"encrypt_decrypt"`
+		} `json:"valid"`
+		Invalid struct {
+			GetConversationKey []struct {
+				Sec1 string `json:"sec1"`
+				Pub2 string `json:"pub2"`
+			} `json:"get_conversation_key"`
+			Decrypt []struct {
+				ConversationKey string `json:"conversation_key"`
+				Payload         string `json:"payload"`
+			} `json:"decrypt"`
+		} `json:"invalid"`
+	} `json:"v2"`
+}
+
+func main() {
+	var (
+		data []byte
+		v    vectors
+		err  error
+	)
+	if data, err = os.ReadFile(vectorsPath); err != nil {
+		log.Fatal(err)
+	}
+	if err = json.Unmarshal(data, &v); err != nil {
+		log.Fatal(err)
+	}
+	// only generated seeds are removed, failing inputs found by go test -fuzz are kept as regression seeds
+	for _, target := range []string{"FuzzDecrypt", "FuzzEncryptDecrypt", "FuzzPad", "FuzzUnpad", "FuzzParsePayload", "FuzzGenerateConversationKey"} {
+		for _, prefix := range []string{"valid_", "invalid_", "calc_padded_len_"} {
+			var files []string
+			if files, err = filepath.Glob(filepath.Join(corpusPath, target, prefix+"*")); err != nil {
+				log.Fatal(err)
+			}
+			for _, file := range files {
+				if err = os.Remove(file); err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+	}
+	for i, e := range v.V2.Valid.EncryptDecrypt {
+		name := fmt.Sprintf("valid_%d", i)
+		plaintext := []byte(e.Plaintext)
+		write("FuzzDecrypt", name, unhex(e.ConversationKey), e.Payload)
+		write("FuzzEncryptDecrypt", name, unhex(e.ConversationKey), unhex(e.Nonce), e.Plaintext)
+		write("FuzzParsePayload", name, e.Payload)
+		write("FuzzPad", name, plaintext)
+		write("FuzzUnpad", name, pad(plaintext))
+	}
+	for i, e := range v.V2.Invalid.Decrypt {
+		name := fmt.Sprintf("invalid_%d", i)
+		write("FuzzDecrypt", name, unhex(e.ConversationKey), e.Payload)
+		write("FuzzParsePayload", name, e.Payload)
+	}
+	for i, e := range v.V2.Valid.CalcPaddedLen {
+		if e[0] <= maxPadSeedLen {
+			write("FuzzPad", fmt.Sprintf("calc_padded_len_%d", i), bytes.Repeat([]byte{'a'}, e[0]))
+		}
+	}
+	for i, e := range v.V2.Valid.GetConversationKey {
+		write("FuzzGenerateConversationKey", fmt.Sprintf("valid_%d", i), unhex(e.Sec1), unhex(e.Pub2))
+	}
+	for i, e := range v.V2.Invalid.GetConversationKey {
+		write("FuzzGenerateConversationKey", fmt.Sprintf("invalid_%d", i), unhex(e.Sec1), unhex(e.Pub2))
+	}
+}
+
+// write writes a corpus file in the format of go test fuzz v1.
+func write(target string, name string, values ...any) {
+	var b bytes.Buffer
+	b.WriteString("go test fuzz v1\n")
+	for _, value := range values {
+		switch value := value.(type) {
+		case []byte:
+			fmt.Fprintf(&b, "[]byte(%q)\n", value)
+		case string:
+			fmt.Fprintf(&b, "string(%q)\n", value)
+		default:
+			log.Fatalf("unsupported type %T", value)
+		}
+	}
+	dir := filepath.Join(corpusPath, target)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), b.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func unhex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return b
+}
+
+// pad returns the plaintext with length prefix and zero padding as specified by NIP-44.
+func pad(plaintext []byte) []byte {
+	padded := make([]byte, 2+calcPadding(len(plaintext)))
+	binary.BigEndian.PutUint16(padded, uint16(len(plaintext)))
+	copy(padded[2:], plaintext)
+	return padded
+}
+
+func calcPadding(n int) int {
+	if n <= 32 {
+		return 32
+	}
+	nextPower := 1 << bits.Len(uint(n-1))
+	chunk := max(32, nextPower/8)
+	return chunk * ((n-1)/chunk + 1)
+}
@@ -0,0 +1,132 @@
+package nip19
+
+import (
+	"errors"
+	"strings"
+)
+
+// Bech32 as specified in BIP-173, without the 90 character limit since TLV entities can be longer.
+// See https://github.com/bitcoin/bips/blob/master/bip-0173.mediawiki
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var (
+	errMixedCase        = errors.New("bech32: mixed case")
+	errInvalidSeparator = errors.New("bech32: invalid separator position")
+	errInvalidCharacter = errors.New("bech32: invalid character")
+	errInvalidChecksum  = errors.New("bech32: invalid checksum")
+	errInvalidPadding   = errors.New("bech32: invalid padding")
+)
+
+func bech32Polymod(values []byte) uint32 {
+	var (
+		gen = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+		chk = uint32(1)
+	)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (b>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	values := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	return values
+}
+
+// bech32Encode encodes 8-bit data with the given human-readable part.
+func bech32Encode(hrp string, data []byte) string {
+	var (
+		values = convertBits(data)
+		sb     strings.Builder
+	)
+	polymod := bech32Polymod(append(append(bech32HrpExpand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+	for i := 0; i < 6; i++ {
+		values = append(values, byte(polymod>>(5*(5-i)))&31)
+	}
+	sb.Grow(len(hrp) + 1 + len(values))
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(bech32Charset[v])
+	}
+	return sb.String()
+}
+
+// bech32Decode decodes a bech32 string into its human-readable part and 8-bit data.
+func bech32Decode(s string) (string, []byte, error) {
+	var (
+		pos    int
+		hrp    string
+		values []byte
+		data   []byte
+		acc    uint32
+		bits   uint
+	)
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, errMixedCase
+	}
+	s = strings.ToLower(s)
+	if pos = strings.LastIndexByte(s, '1'); pos < 1 || pos+7 > len(s) {
+		return "", nil, errInvalidSeparator
+	}
+	hrp = s[:pos]
+	values = bech32HrpExpand(hrp)
+	for i := pos + 1; i < len(s); i++ {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v < 0 {
+			return "", nil, errInvalidCharacter
+		}
+		values = append(values, byte(v))
+	}
+	if bech32Polymod(values) != 1 {
+		return "", nil, errInvalidChecksum
+	}
+	// convert the 5-bit groups without hrp and checksum to bytes
+	for _, v := range values[2*len(hrp)+1 : len(values)-6] {
+		acc = acc<<5 | uint32(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			data = append(data, byte(acc>>bits))
+		}
+	}
+	if bits >= 5 || acc&(1<<bits-1) != 0 {
+		return "", nil, errInvalidPadding
+	}
+	return hrp, data, nil
+}
+
+// convertBits converts bytes to 5-bit groups, padding the last group with zeros.
+func convertBits(data []byte) []byte {
+	var (
+		values = make([]byte, 0, (len(data)*8+4)/5+6)
+		acc    uint32
+		bits   uint
+	)
+	for _, b := range data {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			values = append(values, byte(acc>>bits)&31)
+		}
+	}
+	if bits > 0 {
+		values = append(values, byte(acc<<(5-bits))&31)
+	}
+	return values
+}
@@ -0,0 +1,78 @@
+package nip19
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ekzyis/nip44"
+)
+
+// ParsePrivateKey decodes a private key given as hex or nsec.
+func ParsePrivateKey(s string) ([]byte, error) {
+	var (
+		prefix string
+		value  any
+		b      []byte
+		err    error
+	)
+	if !strings.HasPrefix(strings.ToLower(s), PrefixPrivateKey+"1") {
+		if b, err = hex.DecodeString(s); err != nil || len(b) != 32 {
+			return nil, &nip44.Error{Err: nip44.ErrInvalidPrivateKey, Cause: err}
+		}
+		return b, nil
+	}
+	if prefix, value, err = Decode(s); err != nil {
+		return nil, &nip44.Error{Err: nip44.ErrInvalidPrivateKey, Cause: err}
+	}
+	if prefix != PrefixPrivateKey {
+		return nil, &nip44.Error{Err: nip44.ErrInvalidPrivateKey, Cause: fmt.Errorf("%w: %s", ErrUnknownPrefix, prefix)}
+	}
+	return value.([]byte), nil
+}
+
+// ParsePublicKey decodes a public key given as npub, nprofile or hex. Hex keys can be
+// x-only, compressed or uncompressed.
+func ParsePublicKey(s string) ([]byte, error) {
+	var (
+		prefix string
+		value  any
+		b      []byte
+		err    error
+	)
+	if l := strings.ToLower(s); !strings.HasPrefix(l, PrefixPublicKey+"1") && !strings.HasPrefix(l, PrefixProfile+"1") {
+		if b, err = hex.DecodeString(s); err != nil || (len(b) != 32 && len(b) != 33 && len(b) != 65) {
+			return nil, &nip44.Error{Err: nip44.ErrInvalidPublicKey, Cause: err}
+		}
+		return b, nil
+	}
+	if prefix, value, err = Decode(s); err != nil {
+		return nil, &nip44.Error{Err: nip44.ErrInvalidPublicKey, Cause: err}
+	}
+	switch prefix {
+	case PrefixPublicKey:
+		return value.([]byte), nil
+	case PrefixProfile:
+		return value.(Profile).PubKey, nil
+	}
+	return nil, &nip44.Error{Err: nip44.ErrInvalidPublicKey, Cause: fmt.Errorf("%w: %s", ErrUnknownPrefix, prefix)}
+}
+
+// ConversationKey returns the conversation key of a private and public key given as user-facing
+// strings, see ParsePrivateKey and ParsePublicKey.
+func ConversationKey(sec string, pub string) (nip44.ConversationKey, error) {
+	var (
+		key     nip44.ConversationKey
+		privkey []byte
+		pubkey  []byte
+		err     error
+	)
+	if privkey, err = ParsePrivateKey(sec); err != nil {
+		return key, err
+	}
+	defer clear(privkey)
+	if pubkey, err = ParsePublicKey(pub); err != nil {
+		return key, err
+	}
+	return nip44.NewConversationKey(privkey, pubkey)
+}
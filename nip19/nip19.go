@@ -0,0 +1,212 @@
+// Package nip19 encodes and decodes bech32 entities like npub, nsec and nprofile.
+//
+// See https://github.com/nostr-protocol/nips/blob/master/19.md
+package nip19
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	PrefixPublicKey  = "npub"
+	PrefixPrivateKey = "nsec"
+	PrefixNote       = "note"
+	PrefixProfile    = "nprofile"
+	PrefixEvent      = "nevent"
+)
+
+// TLV types of nprofile and nevent
+const (
+	TLVDefault byte = 0
+	TLVRelay   byte = 1
+	TLVAuthor  byte = 2
+	TLVKind    byte = 3
+)
+
+var (
+	ErrUnknownPrefix = errors.New("unknown prefix")
+	ErrInvalidLength = errors.New("invalid length")
+	ErrInvalidTLV    = errors.New("invalid tlv")
+)
+
+type TLV struct {
+	Type  byte
+	Value []byte
+}
+
+// Profile is the content of an nprofile.
+type Profile struct {
+	PubKey []byte
+	Relays []string
+}
+
+// EventPointer is the content of an nevent. Author and Kind are optional, a zero Kind is not encoded.
+type EventPointer struct {
+	ID     []byte
+	Relays []string
+	Author []byte
+	Kind   int
+}
+
+func EncodePublicKey(pubkey []byte) (string, error) {
+	return encode32(PrefixPublicKey, pubkey)
+}
+
+func EncodePrivateKey(privkey []byte) (string, error) {
+	return encode32(PrefixPrivateKey, privkey)
+}
+
+func EncodeNote(id []byte) (string, error) {
+	return encode32(PrefixNote, id)
+}
+
+func EncodeProfile(p Profile) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if len(p.PubKey) != 32 {
+		return "", fmt.Errorf("%s: public key: %w", PrefixProfile, ErrInvalidLength)
+	}
+	data = appendTLV(data, TLVDefault, p.PubKey)
+	if data, err = appendRelays(data, p.Relays); err != nil {
+		return "", err
+	}
+	return bech32Encode(PrefixProfile, data), nil
+}
+
+func EncodeEvent(e EventPointer) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if len(e.ID) != 32 {
+		return "", fmt.Errorf("%s: id: %w", PrefixEvent, ErrInvalidLength)
+	}
+	data = appendTLV(data, TLVDefault, e.ID)
+	if data, err = appendRelays(data, e.Relays); err != nil {
+		return "", err
+	}
+	if e.Author != nil {
+		if len(e.Author) != 32 {
+			return "", fmt.Errorf("%s: author: %w", PrefixEvent, ErrInvalidLength)
+		}
+		data = appendTLV(data, TLVAuthor, e.Author)
+	}
+	if e.Kind != 0 {
+		data = appendTLV(data, TLVKind, binary.BigEndian.AppendUint32(nil, uint32(e.Kind)))
+	}
+	return bech32Encode(PrefixEvent, data), nil
+}
+
+// Decode decodes a bech32 entity. The value is a []byte for npub, nsec and note,
+// a Profile for nprofile and an EventPointer for nevent.
+func Decode(s string) (string, any, error) {
+	var (
+		prefix string
+		data   []byte
+		tlvs   []TLV
+		err    error
+	)
+	if prefix, data, err = bech32Decode(s); err != nil {
+		return "", nil, err
+	}
+	switch prefix {
+	case PrefixPublicKey, PrefixPrivateKey, PrefixNote:
+		if len(data) != 32 {
+			return "", nil, fmt.Errorf("%s: %w", prefix, ErrInvalidLength)
+		}
+		return prefix, data, nil
+	case PrefixProfile, PrefixEvent:
+	default:
+		return "", nil, fmt.Errorf("%w: %s", ErrUnknownPrefix, prefix)
+	}
+	if tlvs, err = ParseTLV(data); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", prefix, err)
+	}
+	if prefix == PrefixProfile {
+		var p Profile
+		for _, tlv := range tlvs {
+			switch tlv.Type {
+			case TLVDefault:
+				p.PubKey = tlv.Value
+			case TLVRelay:
+				p.Relays = append(p.Relays, string(tlv.Value))
+			}
+		}
+		if len(p.PubKey) != 32 {
+			return "", nil, fmt.Errorf("%s: public key: %w", prefix, ErrInvalidLength)
+		}
+		return prefix, p, nil
+	}
+	var e EventPointer
+	for _, tlv := range tlvs {
+		switch tlv.Type {
+		case TLVDefault:
+			e.ID = tlv.Value
+		case TLVRelay:
+			e.Relays = append(e.Relays, string(tlv.Value))
+		case TLVAuthor:
+			if len(tlv.Value) != 32 {
+				return "", nil, fmt.Errorf("%s: author: %w", prefix, ErrInvalidLength)
+			}
+			e.Author = tlv.Value
+		case TLVKind:
+			if len(tlv.Value) != 4 {
+				return "", nil, fmt.Errorf("%s: kind: %w", prefix, ErrInvalidLength)
+			}
+			e.Kind = int(binary.BigEndian.Uint32(tlv.Value))
+		}
+	}
+	if len(e.ID) != 32 {
+		return "", nil, fmt.Errorf("%s: id: %w", prefix, ErrInvalidLength)
+	}
+	return prefix, e, nil
+}
+
+// ParseTLV splits data into type-length-value entries. Unknown types are returned as well
+// and should be ignored by the caller.
+func ParseTLV(data []byte) ([]TLV, error) {
+	var tlvs []TLV
+	for len(data) > 0 {
+		if len(data) < 2 || len(data) < 2+int(data[1]) {
+			return nil, ErrInvalidTLV
+		}
+		tlvs = append(tlvs, TLV{Type: data[0], Value: data[2 : 2+data[1]]})
+		data = data[2+data[1]:]
+	}
+	return tlvs, nil
+}
+
+func appendTLV(dst []byte, typ byte, value []byte) []byte {
+	return append(append(dst, typ, byte(len(value))), value...)
+}
+
+func appendRelays(dst []byte, relays []string) ([]byte, error) {
+	for _, relay := range relays {
+		if len(relay) > 255 {
+			return nil, fmt.Errorf("relay %s: %w", relay, ErrInvalidLength)
+		}
+		dst = appendTLV(dst, TLVRelay, []byte(relay))
+	}
+	return dst, nil
+}
+
+// EncodeBech32 encodes data with the given human-readable part, for entities not defined in NIP-19.
+func EncodeBech32(hrp string, data []byte) string {
+	return bech32Encode(hrp, data)
+}
+
+// DecodeBech32 decodes a bech32 string into its human-readable part and data.
+func DecodeBech32(s string) (string, []byte, error) {
+	return bech32Decode(s)
+}
+
+func encode32(prefix string, b []byte) (string, error) {
+	if len(b) != 32 {
+		return "", fmt.Errorf("%s: %w", prefix, ErrInvalidLength)
+	}
+	return bech32Encode(prefix, b), nil
+}
@@ -0,0 +1,165 @@
+package nip19_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nip19"
+	"github.com/stretchr/testify/assert"
+)
+
+// test vectors from https://github.com/nostr-protocol/nips/blob/master/19.md
+const (
+	npub     = "npub10elfcs4fr0l0r8af98jlmgdh9c8tcxjvz9qkw038js35mp4dma8qzvjptg"
+	npubHex  = "7e7e9c42a91bfef19fa929e5fda1b72e0ebc1a4c1141673e2794234d86addf4e"
+	nsec     = "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5"
+	nsecHex  = "67dea2ed018072d675f5415ecfaed7d2597555e202d85b3d65ea4e58d2d92ffa"
+	nprofile = "nprofile1qqsrhuxx8l9ex335q7he0f09aej04zpazpl0ne2cgukyawd24mayt8gpp4mhxue69uhhytnc9e3k7mgpz4mhxue69uhkg6nzv9ejuumpv34kytnrdaksjlyr9p"
+)
+
+func assertDecode(t *testing.T, s string, expectedPrefix string, expectedValue any) bool {
+	prefix, value, err := nip19.Decode(s)
+	if ok := assert.NoErrorf(t, err, "decode failed: %v", err); !ok {
+		return false
+	}
+	return assert.Equal(t, expectedPrefix, prefix) && assert.Equal(t, expectedValue, value)
+}
+
+func TestKeys(t *testing.T) {
+	var (
+		pub, _  = hex.DecodeString(npubHex)
+		priv, _ = hex.DecodeString(nsecHex)
+		s       string
+		err     error
+	)
+	assertDecode(t, npub, nip19.PrefixPublicKey, pub)
+	assertDecode(t, nsec, nip19.PrefixPrivateKey, priv)
+	s, err = nip19.EncodePublicKey(pub)
+	if assert.NoErrorf(t, err, "encode failed: %v", err) {
+		assert.Equal(t, npub, s)
+	}
+	s, err = nip19.EncodePrivateKey(priv)
+	if assert.NoErrorf(t, err, "encode failed: %v", err) {
+		assert.Equal(t, nsec, s)
+	}
+	_, err = nip19.EncodePublicKey(pub[1:])
+	assert.ErrorIs(t, err, nip19.ErrInvalidLength)
+}
+
+func TestProfile(t *testing.T) {
+	var (
+		pub, _   = hex.DecodeString("3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d")
+		expected = nip19.Profile{PubKey: pub, Relays: []string{"wss://r.x.com", "wss://djbas.sadkb.com"}}
+		s        string
+		err      error
+	)
+	assertDecode(t, nprofile, nip19.PrefixProfile, expected)
+	s, err = nip19.EncodeProfile(expected)
+	if assert.NoErrorf(t, err, "encode failed: %v", err) {
+		assert.Equal(t, nprofile, s)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	var (
+		id, _     = hex.DecodeString(nsecHex)
+		author, _ = hex.DecodeString(npubHex)
+		s         string
+		err       error
+	)
+	for _, e := range []nip19.EventPointer{
+		{ID: id},
+		{ID: id, Relays: []string{"wss://relay.example.com"}, Author: author, Kind: 1059},
+	} {
+		s, err = nip19.EncodeEvent(e)
+		if assert.NoErrorf(t, err, "encode failed: %v", err) {
+			assertDecode(t, s, nip19.PrefixEvent, e)
+		}
+	}
+	s, err = nip19.EncodeNote(id)
+	if assert.NoErrorf(t, err, "encode failed: %v", err) {
+		assertDecode(t, s, nip19.PrefixNote, id)
+	}
+}
+
+func TestParseTLV(t *testing.T) {
+	var (
+		tlvs []nip19.TLV
+		err  error
+	)
+	tlvs, err = nip19.ParseTLV([]byte{0, 1, 0xaa, 9, 0, 1, 2, 0xbb, 0xcc})
+	if assert.NoErrorf(t, err, "parse failed: %v", err) {
+		assert.Equal(t, []nip19.TLV{
+			{Type: 0, Value: []byte{0xaa}},
+			{Type: 9, Value: []byte{}},
+			{Type: 1, Value: []byte{0xbb, 0xcc}},
+		}, tlvs)
+	}
+	_, err = nip19.ParseTLV([]byte{0, 2, 0xaa})
+	assert.ErrorIs(t, err, nip19.ErrInvalidTLV)
+	_, err = nip19.ParseTLV([]byte{0})
+	assert.ErrorIs(t, err, nip19.ErrInvalidTLV)
+}
+
+func TestDecodeFail(t *testing.T) {
+	var err error
+	// checksum
+	_, _, err = nip19.Decode(npub[:len(npub)-1] + "q")
+	assert.Error(t, err)
+	// mixed case
+	_, _, err = nip19.Decode("N" + npub[1:])
+	assert.Error(t, err)
+	// bech32 with unknown prefix
+	_, _, err = nip19.Decode("a12uel5l")
+	assert.ErrorIs(t, err, nip19.ErrUnknownPrefix)
+}
+
+func TestParseKeys(t *testing.T) {
+	var (
+		pub, _  = hex.DecodeString(npubHex)
+		priv, _ = hex.DecodeString(nsecHex)
+		b       []byte
+		err     error
+	)
+	for _, s := range []string{nsec, nsecHex} {
+		b, err = nip19.ParsePrivateKey(s)
+		if assert.NoErrorf(t, err, "parse failed: %v", err) {
+			assert.Equal(t, priv, b)
+		}
+	}
+	for s, expected := range map[string][]byte{
+		npub:           pub,
+		npubHex:        pub,
+		"02" + npubHex: append([]byte{0x02}, pub...),
+	} {
+		b, err = nip19.ParsePublicKey(s)
+		if assert.NoErrorf(t, err, "parse failed: %v", err) {
+			assert.Equal(t, expected, b)
+		}
+	}
+	b, err = nip19.ParsePublicKey(nprofile)
+	if assert.NoErrorf(t, err, "parse failed: %v", err) {
+		assert.Equal(t, "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d", hex.EncodeToString(b))
+	}
+	_, err = nip19.ParsePrivateKey(npub)
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+	_, err = nip19.ParsePrivateKey(npubHex[2:])
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+	_, err = nip19.ParsePublicKey(nsec)
+	assert.ErrorIs(t, err, nip44.ErrInvalidPublicKey)
+}
+
+func TestConversationKey(t *testing.T) {
+	var (
+		key nip44.ConversationKey
+		err error
+	)
+	key, err = nip19.ConversationKey(
+		"nsec1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqsmhltgl",
+		"c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5",
+	)
+	if assert.NoErrorf(t, err, "conversation key failed: %v", err) {
+		assert.Equal(t, "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d", hex.EncodeToString(key[:]))
+	}
+}
@@ -1,21 +1,18 @@
 package nip44
 
 import (
-	"bytes"
-	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
-	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"math/big"
+	"math/bits"
+	"slices"
 
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
-	"golang.org/x/crypto/chacha20"
 	"golang.org/x/crypto/hkdf"
 )
 
@@ -24,118 +21,175 @@ var (
 	MaxPlaintextSize = 0xffff // 65535 (64kb-1) => padded to 64kb
 )
 
+// DefaultRand is the source of randomness for salts if EncryptOptions.Rand is not set.
+var DefaultRand io.Reader = rand.Reader
+
 type EncryptOptions struct {
 	Salt    []byte
 	Version int
+	Rand    io.Reader
 }
 
-func Encrypt(conversationKey []byte, plaintext string, options *EncryptOptions) (string, error) {
-	var (
-		version    int = 2
-		salt       []byte
-		enc        []byte
-		nonce      []byte
-		auth       []byte
-		padded     []byte
-		ciphertext []byte
-		hmac_      []byte
-		concat     []byte
-		err        error
-	)
-	if options.Version != 0 {
-		version = options.Version
-	}
-	if options.Salt != nil {
-		salt = options.Salt
-	} else {
-		if salt, err = randomBytes(32); err != nil {
-			return "", err
-		}
-	}
-	if version != 2 {
-		return "", errors.New(fmt.Sprintf("unknown version %d", version))
+// Option configures encryption. Besides the With* functions, *EncryptOptions is an Option
+// itself so both styles can be used. A nil Option is ignored and means defaults
+// (version 2 and a random salt).
+type Option interface {
+	apply(*EncryptOptions)
+}
+
+type optionFunc func(*EncryptOptions)
+
+func (f optionFunc) apply(o *EncryptOptions) {
+	f(o)
+}
+
+func (o *EncryptOptions) apply(dst *EncryptOptions) {
+	if o == nil {
+		return
 	}
-	if len(salt) != 32 {
-		return "", errors.New("salt must be 32 bytes")
+	if o.Salt != nil {
+		dst.Salt = o.Salt
 	}
-	if enc, nonce, auth, err = messageKeys(conversationKey, salt); err != nil {
-		return "", err
+	if o.Version != 0 {
+		dst.Version = o.Version
 	}
-	if padded, err = pad(plaintext); err != nil {
-		return "", err
+	if o.Rand != nil {
+		dst.Rand = o.Rand
 	}
-	if ciphertext, err = chacha20_(enc, nonce, []byte(padded)); err != nil {
-		return "", err
+}
+
+func WithSalt(salt []byte) Option {
+	return optionFunc(func(o *EncryptOptions) { o.Salt = salt })
+}
+
+func WithVersion(version int) Option {
+	return optionFunc(func(o *EncryptOptions) { o.Version = version })
+}
+
+func WithRand(r io.Reader) Option {
+	return optionFunc(func(o *EncryptOptions) { o.Rand = r })
+}
+
+func applyOptions(options []Option) EncryptOptions {
+	var o EncryptOptions
+	for _, option := range options {
+		if option != nil {
+			option.apply(&o)
+		}
 	}
-	if hmac_, err = sha256Hmac(auth, ciphertext, salt); err != nil {
+	return o
+}
+
+func Encrypt(conversationKey []byte, plaintext string, options ...Option) (string, error) {
+	var (
+		payload []byte
+		err     error
+	)
+	if payload, err = EncryptBytes(conversationKey, []byte(plaintext), options...); err != nil {
 		return "", err
 	}
-	concat = append(concat, []byte{byte(version)}...)
-	concat = append(concat, salt...)
-	concat = append(concat, ciphertext...)
-	concat = append(concat, hmac_...)
-	return base64.StdEncoding.EncodeToString(concat), nil
+	return string(payload), nil
 }
 
-func Decrypt(conversationKey []byte, ciphertext string) (string, error) {
+func EncryptBytes(conversationKey []byte, plaintext []byte, options ...Option) ([]byte, error) {
+	return AppendEncrypt(nil, conversationKey, plaintext, options...)
+}
+
+// AppendEncrypt encrypts plaintext with the scheme of the requested version
+// and appends the encoded payload to dst.
+func AppendEncrypt(dst []byte, conversationKey []byte, plaintext []byte, options ...Option) ([]byte, error) {
 	var (
-		version     int = 2
-		decoded     []byte
-		cLen        int
-		dLen        int
-		salt        []byte
-		ciphertext_ []byte
-		hmac        []byte
-		hmac_       []byte
-		enc         []byte
-		nonce       []byte
-		auth        []byte
-		padded      []byte
-		unpaddedLen uint16
-		unpadded    []byte
-		err         error
+		o      = applyOptions(options)
+		scheme Scheme
+		ok     bool
+		err    error
 	)
-	cLen = len(ciphertext)
-	if cLen < 132 || cLen > 87472 {
-		return "", errors.New(fmt.Sprintf("invalid payload length: %d", cLen))
-	}
-	if ciphertext[0:1] == "#" {
-		return "", errors.New("unknown version")
-	}
-	if decoded, err = base64.StdEncoding.DecodeString(ciphertext); err != nil {
-		return "", errors.New("invalid base64")
+	if o.Version == 0 {
+		o.Version = DefaultVersion
 	}
-	if version = int(decoded[0]); version != 2 {
-		return "", errors.New(fmt.Sprintf("unknown version %d", version))
+	if scheme, ok = LookupScheme(o.Version); !ok {
+		return nil, &Error{Err: ErrUnknownVersion, Version: o.Version}
 	}
-	dLen = len(decoded)
-	if dLen < 99 || dLen > 65603 {
-		return "", errors.New(fmt.Sprintf("invalid data length: %d", dLen))
-	}
-	salt, ciphertext_, hmac_ = decoded[1:33], decoded[33:dLen-32], decoded[dLen-32:]
-	if enc, nonce, auth, err = messageKeys(conversationKey, salt); err != nil {
-		return "", err
+	if err = checkPlaintextLen(scheme, plaintext); err != nil {
+		return nil, err
 	}
-	if hmac, err = sha256Hmac(auth, ciphertext_, salt); err != nil {
-		return "", err
+	return scheme.AppendEncrypt(dst, conversationKey, plaintext, o)
+}
+
+// v2 is the scheme of version 2.
+type v2 struct{}
+
+func (v2) MinPlaintextLen() int { return MinPlaintextSize }
+func (v2) MaxPlaintextLen() int { return MaxPlaintextSize }
+func (v2) MinPayloadLen() int   { return 132 }
+func (v2) MaxPayloadLen() int   { return 87472 }
+
+// checkPayload checks that the ciphertext can hold the length prefix and a padded plaintext.
+// The base64 decoder skips newlines, so the length of the encoded payload is not enough.
+func (v2) checkPayload(p *Payload, payload []byte) error {
+	if len(p.Ciphertext) < 2+calcPadding(MinPlaintextSize) || len(p.Ciphertext) > 2+calcPadding(MaxPlaintextSize) {
+		return &Error{Err: ErrInvalidPayloadLength, Length: len(payload)}
 	}
-	if !bytes.Equal(hmac_, hmac) {
-		return "", errors.New("invalid hmac")
+	return nil
+}
+
+func (s v2) AppendEncrypt(dst []byte, conversationKey []byte, plaintext []byte, o EncryptOptions) ([]byte, error) {
+	c := cipherPool.Get().(*Cipher)
+	defer cipherPool.Put(c)
+	defer c.Wipe()
+	if err := c.Reset(conversationKey); err != nil {
+		return nil, err
 	}
-	if padded, err = chacha20_(enc, nonce, ciphertext_); err != nil {
+	return s.appendEncryptCipher(c, dst, plaintext, o)
+}
+
+func (v2) appendEncryptCipher(c *Cipher, dst []byte, plaintext []byte, o EncryptOptions) ([]byte, error) {
+	return c.appendEncrypt(dst, plaintext, o)
+}
+
+func Decrypt(conversationKey []byte, ciphertext string) (string, error) {
+	var (
+		plaintext []byte
+		err       error
+	)
+	if plaintext, err = DecryptBytes(conversationKey, []byte(ciphertext)); err != nil {
 		return "", err
 	}
-	unpaddedLen = binary.BigEndian.Uint16(padded[0:2])
-	if unpaddedLen < uint16(MinPlaintextSize) || unpaddedLen > uint16(MaxPlaintextSize) || len(padded) != 2+calcPadding(int(unpaddedLen)) {
-		return "", errors.New("invalid padding")
+	return string(plaintext), nil
+}
+
+func DecryptBytes(conversationKey []byte, payload []byte) ([]byte, error) {
+	return AppendDecrypt(nil, conversationKey, payload)
+}
+
+// AppendDecrypt decrypts the payload with the scheme of its version and appends the plaintext to dst.
+func AppendDecrypt(dst []byte, conversationKey []byte, payload []byte) ([]byte, error) {
+	var (
+		scheme Scheme
+		err    error
+	)
+	if scheme, err = payloadScheme(payload); err != nil {
+		return nil, err
 	}
-	unpadded = padded[2 : unpaddedLen+2]
-	if len(unpadded) == 0 || len(unpadded) != int(unpaddedLen) {
-		return "", errors.New("invalid padding")
+	return scheme.AppendDecrypt(dst, conversationKey, payload)
+}
+
+func (s v2) AppendDecrypt(dst []byte, conversationKey []byte, payload []byte) ([]byte, error) {
+	c := cipherPool.Get().(*Cipher)
+	defer cipherPool.Put(c)
+	defer c.Wipe()
+	if err := c.Reset(conversationKey); err != nil {
+		return nil, err
 	}
-	return string(unpadded), nil
+	return s.appendDecryptCipher(c, dst, payload)
 }
 
+func (v2) appendDecryptCipher(c *Cipher, dst []byte, payload []byte) ([]byte, error) {
+	return c.AppendDecrypt(dst, payload)
+}
+
+// GenerateConversationKey returns the conversation key between the private key and the public key.
+// The public key can be a 32-byte x-only nostr key or a compressed or uncompressed SEC1 key.
 func GenerateConversationKey(sendPrivkey []byte, recvPubkey []byte) ([]byte, error) {
 	var (
 		N   = secp256k1.S256().N
@@ -147,45 +201,35 @@ func GenerateConversationKey(sendPrivkey []byte, recvPubkey []byte) ([]byte, err
 	// see https://pkg.go.dev/github.com/decred/dcrd/dcrec/secp256k1/v4#PrivKeyFromBytes
 	skX := new(big.Int).SetBytes(sendPrivkey)
 	if skX.Cmp(big.NewInt(0)) == 0 || skX.Cmp(N) >= 0 {
-		return []byte{}, fmt.Errorf("invalid private key: x coordinate %s is not on the secp256k1 curve", hex.EncodeToString(sendPrivkey))
+		return []byte{}, &Error{
+			Err:   ErrInvalidPrivateKey,
+			Cause: fmt.Errorf("x coordinate %s is not on the secp256k1 curve", hex.EncodeToString(sendPrivkey)),
+		}
 	}
 	sk = secp256k1.PrivKeyFromBytes(sendPrivkey)
-	if pk, err = secp256k1.ParsePubKey(recvPubkey); err != nil {
-		return []byte{}, err
+	if pk, err = parsePublicKey(recvPubkey); err != nil {
+		return []byte{}, &Error{Err: ErrInvalidPublicKey, Cause: err}
 	}
 	shared := secp256k1.GenerateSharedSecret(sk, pk)
 	return hkdf.Extract(sha256.New, shared, []byte("nip44-v2")), nil
 }
 
-func chacha20_(key []byte, nonce []byte, message []byte) ([]byte, error) {
+// parsePublicKey parses an x-only, compressed or uncompressed public key into the point with
+// the same x coordinate and even y, as nostr public keys only encode x.
+func parsePublicKey(pubkey []byte) (*secp256k1.PublicKey, error) {
 	var (
-		cipher *chacha20.Cipher
-		dst    = make([]byte, len(message))
-		err    error
+		pk  *secp256k1.PublicKey
+		err error
 	)
-	if cipher, err = chacha20.NewUnauthenticatedCipher(key, nonce); err != nil {
-		return nil, err
+	if len(pubkey) == 32 {
+		return secp256k1.ParsePubKey(append([]byte{secp256k1.PubKeyFormatCompressedEven}, pubkey...))
 	}
-	cipher.XORKeyStream(dst, message)
-	return dst, nil
-}
-
-func randomBytes(n int) ([]byte, error) {
-	buf := make([]byte, n)
-	if _, err := rand.Read(buf); err != nil {
+	if pk, err = secp256k1.ParsePubKey(pubkey); err != nil {
 		return nil, err
 	}
-	return buf, nil
-}
-
-func sha256Hmac(key []byte, ciphertext []byte, aad []byte) ([]byte, error) {
-	if len(aad) != 32 {
-		return nil, errors.New("aad data must be 32 bytes")
-	}
-	h := hmac.New(sha256.New, key)
-	h.Write(aad)
-	h.Write(ciphertext)
-	return h.Sum(nil), nil
+	compressed := pk.SerializeCompressed()
+	compressed[0] = secp256k1.PubKeyFormatCompressedEven
+	return secp256k1.ParsePubKey(compressed)
 }
 
 func messageKeys(conversationKey []byte, salt []byte) ([]byte, []byte, []byte, error) {
@@ -197,7 +241,7 @@ func messageKeys(conversationKey []byte, salt []byte) ([]byte, []byte, []byte, e
 		err   error
 	)
 	if len(conversationKey) != 32 {
-		return nil, nil, nil, errors.New("conversation key must be 32 bytes")
+		return nil, nil, nil, ErrInvalidConversationKey
 	}
 	if len(salt) != 32 {
 		return nil, nil, nil, errors.New("salt must be 32 bytes")
@@ -215,24 +259,34 @@ func messageKeys(conversationKey []byte, salt []byte) ([]byte, []byte, []byte, e
 	return enc, nonce, auth, nil
 }
 
-func pad(s string) ([]byte, error) {
+func appendBase64(dst []byte, src []byte) []byte {
 	var (
-		sb      []byte
-		sbLen   int
-		padding int
-		result  []byte
+		n    = len(dst)
+		eLen = base64.StdEncoding.EncodedLen(len(src))
 	)
-	sb = []byte(s)
-	sbLen = len(sb)
-	if sbLen < 1 || sbLen > MaxPlaintextSize {
-		return nil, errors.New("plaintext should be between 1b and 64kB")
+	dst = slices.Grow(dst, eLen)[:n+eLen]
+	base64.StdEncoding.Encode(dst[n:], src)
+	return dst
+}
+
+// PaddedLen returns the length of the padded plaintext for a plaintext of n bytes,
+// excluding the 2-byte length prefix.
+func PaddedLen(n int) (int, error) {
+	if n < MinPlaintextSize || n > MaxPlaintextSize {
+		return 0, ErrInvalidPlaintextLength
+	}
+	return calcPadding(n), nil
+}
+
+// PayloadLen returns the length of the base64 encoded payload for a plaintext of n bytes
+// or 0 if n is not a valid plaintext length.
+func PayloadLen(n int) int {
+	padded, err := PaddedLen(n)
+	if err != nil {
+		return 0
 	}
-	padding = calcPadding(sbLen)
-	result = make([]byte, 2)
-	binary.BigEndian.PutUint16(result, uint16(sbLen))
-	result = append(result, sb...)
-	result = append(result, make([]byte, padding-sbLen)...)
-	return result, nil
+	// version (1) + salt (32) + length prefix (2) + padded plaintext + mac (32)
+	return base64.StdEncoding.EncodedLen(1 + 32 + 2 + padded + 32)
 }
 
 func calcPadding(sLen int) int {
@@ -243,7 +297,7 @@ func calcPadding(sLen int) int {
 	if sLen <= 32 {
 		return 32
 	}
-	nextPower = 1 << int(math.Floor(math.Log2(float64(sLen-1)))+1)
-	chunk = int(math.Max(32, float64(nextPower/8)))
-	return chunk * int(math.Floor(float64((sLen-1)/chunk))+1)
+	nextPower = 1 << bits.Len(uint(sLen-1))
+	chunk = max(32, nextPower/8)
+	return chunk * ((sLen-1)/chunk + 1)
 }
@@ -0,0 +1,290 @@
+package nip46
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nostr"
+)
+
+// Client sends requests to a remote signer. The client key only authenticates the client
+// to the remote signer and encrypts the requests, it is not the key of the user.
+type Client struct {
+	transport Transport
+	key       []byte
+	pubkey    string
+	remotePub string
+	mu        sync.Mutex
+	userPub   string
+	pending   map[string]chan response
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewClient subscribes to the responses of the remote signer with the given public key.
+// Close must be called to end the subscription.
+func NewClient(transport Transport, clientKey []byte, remotePub string) (*Client, error) {
+	var (
+		c = &Client{
+			transport: transport,
+			key:       append([]byte(nil), clientKey...),
+			remotePub: remotePub,
+			pending:   make(map[string]chan response),
+			done:      make(chan struct{}),
+		}
+		ctx    context.Context
+		events <-chan *nostr.Event
+		err    error
+	)
+	if c.pubkey, err = nostr.GetPublicKey(clientKey); err != nil {
+		return nil, err
+	}
+	ctx, c.cancel = context.WithCancel(context.Background())
+	if events, err = transport.Subscribe(ctx, c.pubkey); err != nil {
+		c.cancel()
+		return nil, err
+	}
+	go c.receive(events)
+	return c, nil
+}
+
+// Close ends the subscription and fails pending requests with ErrClosed.
+func (c *Client) Close() {
+	c.cancel()
+	<-c.done
+	clear(c.key)
+}
+
+func (c *Client) receive(events <-chan *nostr.Event) {
+	defer close(c.done)
+	for ev := range events {
+		var (
+			plaintext string
+			res       response
+			err       error
+		)
+		if ev.PubKey != c.remotePub {
+			continue
+		}
+		if plaintext, err = nostr.DecryptEventContent(ev, c.key); err != nil {
+			continue
+		}
+		if err = json.Unmarshal([]byte(plaintext), &res); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		if ch, ok := c.pending[res.ID]; ok {
+			delete(c.pending, res.ID)
+			ch <- res
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *Client) call(ctx context.Context, method string, params ...string) (string, error) {
+	var (
+		id        [16]byte
+		plaintext []byte
+		ev        *nostr.Event
+		ch        = make(chan response, 1)
+		res       response
+		err       error
+	)
+	if _, err = rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	req := request{ID: hex.EncodeToString(id[:]), Method: method, Params: params}
+	if req.Params == nil {
+		req.Params = []string{}
+	}
+	if plaintext, err = json.Marshal(req); err != nil {
+		return "", err
+	}
+	ev = &nostr.Event{CreatedAt: time.Now().Unix(), Kind: KindNostrConnect, Tags: [][]string{{"p", c.remotePub}}}
+	if err = nostr.EncryptEventContent(ev, c.key, string(plaintext)); err != nil {
+		return "", err
+	}
+	if err = ev.Sign(c.key); err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.pending[req.ID] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+	}()
+	if err = c.transport.Publish(ctx, ev); err != nil {
+		return "", err
+	}
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-c.done:
+		return "", ErrClosed
+	}
+	if res.Error != "" {
+		return "", &Error{Method: method, Message: res.Error}
+	}
+	return res.Result, nil
+}
+
+// Connect establishes the connection, using the secret if the remote signer requires one.
+func (c *Client) Connect(ctx context.Context, secret string) error {
+	var (
+		params = []string{c.remotePub}
+		result string
+		err    error
+	)
+	if secret != "" {
+		params = append(params, secret)
+	}
+	if result, err = c.call(ctx, MethodConnect, params...); err != nil {
+		return err
+	}
+	if result != "ack" && (secret == "" || result != secret) {
+		return ErrInvalidResponse
+	}
+	return nil
+}
+
+// GetPublicKey returns the hex encoded public key of the user.
+func (c *Client) GetPublicKey(ctx context.Context) (string, error) {
+	pubkey, err := c.call(ctx, MethodGetPublicKey)
+	if err != nil {
+		return "", err
+	}
+	c.mu.Lock()
+	c.userPub = pubkey
+	c.mu.Unlock()
+	return pubkey, nil
+}
+
+// userPublicKey returns the public key of the user, requesting it if it is not known yet.
+func (c *Client) userPublicKey(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	pubkey := c.userPub
+	c.mu.Unlock()
+	if pubkey != "" {
+		return pubkey, nil
+	}
+	return c.GetPublicKey(ctx)
+}
+
+// SignEvent lets the remote signer sign the event and sets its public key, id and signature.
+// The event must be signed with the key of the user as returned by GetPublicKey.
+func (c *Client) SignEvent(ctx context.Context, ev *nostr.Event) error {
+	var (
+		userPub  string
+		unsigned []byte
+		result   string
+		signed   nostr.Event
+		err      error
+	)
+	if userPub, err = c.userPublicKey(ctx); err != nil {
+		return err
+	}
+	if ev.Tags == nil {
+		ev.Tags = [][]string{}
+	}
+	if unsigned, err = json.Marshal(struct {
+		Kind      int        `json:"kind"`
+		Content   string     `json:"content"`
+		Tags      [][]string `json:"tags"`
+		CreatedAt int64      `json:"created_at"`
+	}{ev.Kind, ev.Content, ev.Tags, ev.CreatedAt}); err != nil {
+		return err
+	}
+	if result, err = c.call(ctx, MethodSignEvent, string(unsigned)); err != nil {
+		return err
+	}
+	if err = json.Unmarshal([]byte(result), &signed); err != nil {
+		return ErrInvalidResponse
+	}
+	// make sure the remote signer signed what we asked for with the key of the user
+	if signed.PubKey != userPub || !signed.Verify() || signed.Kind != ev.Kind || signed.Content != ev.Content ||
+		signed.CreatedAt != ev.CreatedAt || !slices.EqualFunc(signed.Tags, ev.Tags, slices.Equal[[]string]) {
+		return ErrInvalidResponse
+	}
+	*ev = signed
+	return nil
+}
+
+// NIP44Encrypt encrypts the plaintext for the hex encoded public key with the key of the user.
+func (c *Client) NIP44Encrypt(ctx context.Context, pubkey string, plaintext string) (string, error) {
+	return c.call(ctx, MethodNIP44Encrypt, pubkey, plaintext)
+}
+
+// NIP44Decrypt decrypts the payload from the hex encoded public key with the key of the user.
+func (c *Client) NIP44Decrypt(ctx context.Context, pubkey string, payload string) (string, error) {
+	return c.call(ctx, MethodNIP44Decrypt, pubkey, payload)
+}
+
+func (c *Client) Ping(ctx context.Context) error {
+	result, err := c.call(ctx, MethodPing)
+	if err == nil && result != "pong" {
+		return ErrInvalidResponse
+	}
+	return err
+}
+
+// Signer returns a nip44.Signer which sends its requests with the given context.
+// The remote signer never reveals conversation keys, so ConversationKey is not supported.
+func (c *Client) Signer(ctx context.Context) nip44.Signer {
+	return &remoteSigner{c: c, ctx: ctx}
+}
+
+type remoteSigner struct {
+	c   *Client
+	ctx context.Context
+}
+
+func (s *remoteSigner) PublicKey() ([]byte, error) {
+	pubkey, err := s.c.GetPublicKey(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(pubkey)
+}
+
+func (s *remoteSigner) ConversationKey(peerPub []byte) (nip44.ConversationKey, error) {
+	return nip44.ConversationKey{}, errors.ErrUnsupported
+}
+
+func (s *remoteSigner) Encrypt(peerPub []byte, plaintext string) (string, error) {
+	pubkey, err := xonlyHex(peerPub)
+	if err != nil {
+		return "", err
+	}
+	return s.c.NIP44Encrypt(s.ctx, pubkey, plaintext)
+}
+
+func (s *remoteSigner) Decrypt(peerPub []byte, payload string) (string, error) {
+	pubkey, err := xonlyHex(peerPub)
+	if err != nil {
+		return "", err
+	}
+	return s.c.NIP44Decrypt(s.ctx, pubkey, payload)
+}
+
+// xonlyHex converts a public key in any encoding to the hex encoded x-only form used by nostr.
+func xonlyHex(pubkey []byte) (string, error) {
+	if len(pubkey) == 32 {
+		return hex.EncodeToString(pubkey), nil
+	}
+	pk, err := secp256k1.ParsePubKey(pubkey)
+	if err != nil {
+		return "", &nip44.Error{Err: nip44.ErrInvalidPublicKey, Cause: err}
+	}
+	return hex.EncodeToString(pk.SerializeCompressed()[1:]), nil
+}
@@ -0,0 +1,7 @@
+package nip46
+
+func (r *Relay) Subscriptions() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.subs)
+}
@@ -0,0 +1,49 @@
+// Package nip46 implements remote signing: a client asks a remote signer (bunker) which holds
+// the private key to sign events and to encrypt and decrypt with NIP-44. Requests and responses
+// are NIP-44 encrypted events of kind 24133 exchanged over a Transport.
+//
+// See https://github.com/nostr-protocol/nips/blob/master/46.md
+package nip46
+
+import (
+	"errors"
+	"fmt"
+)
+
+const KindNostrConnect = 24133
+
+const (
+	MethodConnect      = "connect"
+	MethodGetPublicKey = "get_public_key"
+	MethodSignEvent    = "sign_event"
+	MethodNIP44Encrypt = "nip44_encrypt"
+	MethodNIP44Decrypt = "nip44_decrypt"
+	MethodPing         = "ping"
+)
+
+var (
+	ErrClosed          = errors.New("client closed")
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+type request struct {
+	ID     string   `json:"id"`
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+}
+
+type response struct {
+	ID     string `json:"id"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Error is an error returned by the remote signer.
+type Error struct {
+	Method  string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Method, e.Message)
+}
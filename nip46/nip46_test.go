@@ -0,0 +1,232 @@
+package nip46_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nip46"
+	"github.com/ekzyis/nip44/nostr"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	sk1, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	sk2, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000002")
+	sk3, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000003")
+	pub2   = "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+	pub3   = "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"
+)
+
+// setup starts a remote signer for sk2 and returns a client with sk1 as client key.
+func setup(t *testing.T, secret string) *nip46.Client {
+	var (
+		relay       = nip46.NewRelay()
+		ctx, cancel = context.WithCancel(context.Background())
+		server      *nip46.Server
+		client      *nip46.Client
+		err         error
+	)
+	t.Cleanup(cancel)
+	if server, err = nip46.NewServer(relay, sk2, secret); err != nil {
+		t.Fatalf("creating server failed: %v", err)
+	}
+	go server.Serve(ctx)
+	// requests are lost if the server did not subscribe yet
+	for relay.Subscriptions() == 0 {
+		runtime.Gosched()
+	}
+	if client, err = nip46.NewClient(relay, sk1, server.PublicKey()); err != nil {
+		t.Fatalf("creating client failed: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+// fakeSigner answers requests to the remote signer sk2 with the result of respond and returns
+// a client with sk1 as client key.
+func fakeSigner(t *testing.T, respond func(method string, params []string) string) *nip46.Client {
+	var (
+		relay       = nip46.NewRelay()
+		ctx, cancel = context.WithCancel(context.Background())
+		events      <-chan *nostr.Event
+		client      *nip46.Client
+		err         error
+	)
+	t.Cleanup(cancel)
+	if events, err = relay.Subscribe(ctx, pub2); err != nil {
+		t.Fatalf("subscribing failed: %v", err)
+	}
+	go func() {
+		for ev := range events {
+			var req struct {
+				ID     string   `json:"id"`
+				Method string   `json:"method"`
+				Params []string `json:"params"`
+			}
+			plaintext, err := nostr.DecryptEventContent(ev, sk2)
+			if err != nil || json.Unmarshal([]byte(plaintext), &req) != nil {
+				continue
+			}
+			b, _ := json.Marshal(map[string]string{"id": req.ID, "result": respond(req.Method, req.Params)})
+			reply := &nostr.Event{CreatedAt: time.Now().Unix(), Kind: nip46.KindNostrConnect, Tags: [][]string{{"p", ev.PubKey}}}
+			if nostr.EncryptEventContent(reply, sk2, string(b)) != nil || reply.Sign(sk2) != nil {
+				continue
+			}
+			relay.Publish(ctx, reply)
+		}
+	}()
+	if client, err = nip46.NewClient(relay, sk1, pub2); err != nil {
+		t.Fatalf("creating client failed: %v", err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestClientServer(t *testing.T) {
+	var (
+		ctx       = testContext(t)
+		client    = setup(t, "s3cr3t")
+		pubkey    string
+		payload   string
+		plaintext string
+		remoteErr *nip46.Error
+		err       error
+	)
+	_, err = client.GetPublicKey(ctx)
+	if assert.ErrorAs(t, err, &remoteErr) {
+		assert.Equal(t, "get_public_key: not connected", remoteErr.Error())
+	}
+	err = client.Connect(ctx, "wrong")
+	assert.ErrorAs(t, err, &remoteErr)
+	err = client.Connect(ctx, "s3cr3t")
+	if ok := assert.NoErrorf(t, err, "connect failed: %v", err); !ok {
+		return
+	}
+	assert.NoError(t, client.Ping(ctx))
+	pubkey, err = client.GetPublicKey(ctx)
+	if assert.NoErrorf(t, err, "get_public_key failed: %v", err) {
+		assert.Equal(t, pub2, pubkey)
+	}
+
+	ev := &nostr.Event{CreatedAt: 1700000000, Kind: 1, Tags: [][]string{{"t", "nip46"}}, Content: "hello"}
+	err = client.SignEvent(ctx, ev)
+	if assert.NoErrorf(t, err, "sign_event failed: %v", err) {
+		assert.Equal(t, pub2, ev.PubKey)
+		assert.True(t, ev.Verify())
+	}
+
+	payload, err = client.NIP44Encrypt(ctx, pub3, "hola")
+	if ok := assert.NoErrorf(t, err, "nip44_encrypt failed: %v", err); !ok {
+		return
+	}
+	key, _ := nostr.ConversationKey(sk3, pub2)
+	plaintext, err = key.Decrypt(payload)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, "hola", plaintext)
+	}
+	payload, _ = key.Encrypt("que tal")
+	plaintext, err = client.NIP44Decrypt(ctx, pub3, payload)
+	if assert.NoErrorf(t, err, "nip44_decrypt failed: %v", err) {
+		assert.Equal(t, "que tal", plaintext)
+	}
+	_, err = client.NIP44Decrypt(ctx, pub3, "invalid")
+	assert.ErrorAs(t, err, &remoteErr)
+}
+
+func TestClientSignEventWrongKey(t *testing.T) {
+	var (
+		ctx    = testContext(t)
+		client = fakeSigner(t, func(method string, params []string) string {
+			var ev nostr.Event
+			switch method {
+			case nip46.MethodGetPublicKey:
+				return pub2
+			case nip46.MethodSignEvent:
+				// a valid signature, but not by the key of the user
+				json.Unmarshal([]byte(params[0]), &ev)
+				ev.Sign(sk3)
+				b, _ := json.Marshal(ev)
+				return string(b)
+			}
+			return ""
+		})
+		ev = &nostr.Event{CreatedAt: 1700000000, Kind: 1, Tags: [][]string{}, Content: "hello"}
+	)
+	assert.ErrorIs(t, client.SignEvent(ctx, ev), nip46.ErrInvalidResponse)
+	assert.Empty(t, ev.Sig)
+}
+
+func TestClientSigner(t *testing.T) {
+	var (
+		ctx       = testContext(t)
+		client    = setup(t, "")
+		signer    nip44.Signer
+		local, _  = nip44.NewKeySigner(sk3)
+		pub2b, _  = hex.DecodeString(pub2)
+		pub3b, _  = hex.DecodeString(pub3)
+		pubkey    []byte
+		payload   string
+		plaintext string
+		err       error
+	)
+	if err = client.Connect(ctx, ""); err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	signer = client.Signer(ctx)
+	pubkey, err = signer.PublicKey()
+	if assert.NoErrorf(t, err, "public key failed: %v", err) {
+		assert.Equal(t, pub2b, pubkey)
+	}
+	payload, err = signer.Encrypt(pub3b, "hola")
+	if ok := assert.NoErrorf(t, err, "encryption failed: %v", err); !ok {
+		return
+	}
+	plaintext, err = local.Decrypt(pub2b, payload)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, "hola", plaintext)
+	}
+	_, err = signer.ConversationKey(pub3b)
+	assert.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+func TestClientNoServer(t *testing.T) {
+	var (
+		relay       = nip46.NewRelay()
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		client      *nip46.Client
+		err         error
+	)
+	defer cancel()
+	if client, err = nip46.NewClient(relay, sk1, pub2); err != nil {
+		t.Fatalf("creating client failed: %v", err)
+	}
+	err = client.Ping(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	go client.Close()
+	err = client.Ping(context.Background())
+	assert.ErrorIs(t, err, nip46.ErrClosed)
+}
+
+func TestRelayRejectsInvalidEvent(t *testing.T) {
+	var (
+		relay = nip46.NewRelay()
+		ev    = &nostr.Event{Kind: nip46.KindNostrConnect, Tags: [][]string{{"p", pub2}}}
+	)
+	if err := ev.Sign(sk1); err != nil {
+		t.Fatalf("signing failed: %v", err)
+	}
+	ev.Content = "tampered"
+	assert.ErrorIs(t, relay.Publish(context.Background(), ev), nip46.ErrInvalidEvent)
+}
@@ -0,0 +1,179 @@
+package nip46
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ekzyis/nip44/nostr"
+)
+
+var (
+	errNotConnected  = errors.New("not connected")
+	errInvalidSecret = errors.New("invalid secret")
+	errInvalidParams = errors.New("invalid params")
+	errUnknownMethod = errors.New("unknown method")
+)
+
+// Server is a remote signer which services requests with a local key.
+type Server struct {
+	transport Transport
+	key       []byte
+	pubkey    string
+	secret    string
+	mu        sync.Mutex
+	clients   map[string]bool
+}
+
+// NewServer returns a remote signer for the private key. If secret is not empty, clients
+// must send it with their connect request.
+func NewServer(transport Transport, privkey []byte, secret string) (*Server, error) {
+	var (
+		s = &Server{
+			transport: transport,
+			key:       append([]byte(nil), privkey...),
+			secret:    secret,
+			clients:   make(map[string]bool),
+		}
+		err error
+	)
+	if s.pubkey, err = nostr.GetPublicKey(privkey); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// PublicKey returns the hex encoded public key of the remote signer.
+func (s *Server) PublicKey() string {
+	return s.pubkey
+}
+
+// Serve services requests until ctx is done.
+func (s *Server) Serve(ctx context.Context) error {
+	var (
+		events <-chan *nostr.Event
+		err    error
+	)
+	if events, err = s.transport.Subscribe(ctx, s.pubkey); err != nil {
+		return err
+	}
+	for ev := range events {
+		// errors can not be reported for requests which can not be decrypted
+		s.handle(ctx, ev)
+	}
+	return ctx.Err()
+}
+
+func (s *Server) handle(ctx context.Context, ev *nostr.Event) error {
+	var (
+		plaintext string
+		req       request
+		res       response
+		b         []byte
+		err       error
+	)
+	if ev.Kind != KindNostrConnect || !ev.Verify() {
+		return ErrInvalidEvent
+	}
+	if plaintext, err = nostr.DecryptEventContent(ev, s.key); err != nil {
+		return err
+	}
+	if err = json.Unmarshal([]byte(plaintext), &req); err != nil {
+		return err
+	}
+	res.ID = req.ID
+	if res.Result, err = s.dispatch(ev.PubKey, req); err != nil {
+		res.Error = err.Error()
+	}
+	if b, err = json.Marshal(res); err != nil {
+		return err
+	}
+	reply := &nostr.Event{CreatedAt: time.Now().Unix(), Kind: KindNostrConnect, Tags: [][]string{{"p", ev.PubKey}}}
+	if err = nostr.EncryptEventContent(reply, s.key, string(b)); err != nil {
+		return err
+	}
+	if err = reply.Sign(s.key); err != nil {
+		return err
+	}
+	return s.transport.Publish(ctx, reply)
+}
+
+func (s *Server) dispatch(client string, req request) (string, error) {
+	if req.Method == MethodConnect {
+		return s.connect(client, req.Params)
+	}
+	s.mu.Lock()
+	connected := s.clients[client]
+	s.mu.Unlock()
+	if !connected {
+		return "", errNotConnected
+	}
+	switch req.Method {
+	case MethodGetPublicKey:
+		return s.pubkey, nil
+	case MethodSignEvent:
+		return s.signEvent(req.Params)
+	case MethodNIP44Encrypt, MethodNIP44Decrypt:
+		return s.crypt(req.Method, req.Params)
+	case MethodPing:
+		return "pong", nil
+	}
+	return "", errUnknownMethod
+}
+
+func (s *Server) connect(client string, params []string) (string, error) {
+	if len(params) < 1 || params[0] != s.pubkey {
+		return "", errInvalidParams
+	}
+	// compare in constant time so the secret can not be guessed byte by byte
+	if s.secret != "" && (len(params) < 2 || subtle.ConstantTimeCompare([]byte(params[1]), []byte(s.secret)) != 1) {
+		return "", errInvalidSecret
+	}
+	s.mu.Lock()
+	s.clients[client] = true
+	s.mu.Unlock()
+	return "ack", nil
+}
+
+func (s *Server) signEvent(params []string) (string, error) {
+	var (
+		ev  nostr.Event
+		b   []byte
+		err error
+	)
+	if len(params) != 1 {
+		return "", errInvalidParams
+	}
+	if err = json.Unmarshal([]byte(params[0]), &ev); err != nil {
+		return "", errInvalidParams
+	}
+	if ev.Tags == nil {
+		ev.Tags = [][]string{}
+	}
+	ev.PubKey = ""
+	if err = ev.Sign(s.key); err != nil {
+		return "", err
+	}
+	if b, err = json.Marshal(ev); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (s *Server) crypt(method string, params []string) (string, error) {
+	if len(params) != 2 {
+		return "", errInvalidParams
+	}
+	key, err := nostr.ConversationKey(s.key, params[0])
+	if err != nil {
+		return "", err
+	}
+	defer key.Wipe()
+	if method == MethodNIP44Encrypt {
+		return key.Encrypt(params[1])
+	}
+	return key.Decrypt(params[1])
+}
@@ -0,0 +1,105 @@
+package nip46
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ekzyis/nip44/nostr"
+)
+
+// Transport delivers events between client and remote signer, usually via relays.
+type Transport interface {
+	Publish(ctx context.Context, ev *nostr.Event) error
+	// Subscribe returns the events of kind 24133 with a p tag for the public key.
+	// The channel is closed when ctx is done.
+	Subscribe(ctx context.Context, pubkey string) (<-chan *nostr.Event, error)
+}
+
+var ErrInvalidEvent = errors.New("invalid event")
+
+// Relay is an in-memory Transport, mainly for tests.
+type Relay struct {
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+type subscription struct {
+	mu     sync.Mutex
+	pubkey string
+	ch     chan *nostr.Event
+	done   <-chan struct{}
+	closed bool
+}
+
+var _ Transport = (*Relay)(nil)
+
+func NewRelay() *Relay {
+	return &Relay{subs: make(map[*subscription]struct{})}
+}
+
+// Publish delivers the event to all matching subscriptions. Like a relay, it rejects
+// events with an invalid id or signature.
+func (r *Relay) Publish(ctx context.Context, ev *nostr.Event) error {
+	var (
+		p    string
+		ok   bool
+		subs []*subscription
+	)
+	if !ev.Verify() {
+		return ErrInvalidEvent
+	}
+	if p, ok = ev.Tag("p"); !ok || ev.Kind != KindNostrConnect {
+		return nil
+	}
+	r.mu.Lock()
+	for sub := range r.subs {
+		if sub.pubkey == p {
+			subs = append(subs, sub)
+		}
+	}
+	r.mu.Unlock()
+	for _, sub := range subs {
+		if err := sub.send(ctx, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Relay) Subscribe(ctx context.Context, pubkey string) (<-chan *nostr.Event, error) {
+	sub := &subscription{pubkey: pubkey, ch: make(chan *nostr.Event), done: ctx.Done()}
+	r.mu.Lock()
+	r.subs[sub] = struct{}{}
+	r.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		delete(r.subs, sub)
+		r.mu.Unlock()
+		sub.close()
+	}()
+	return sub.ch, nil
+}
+
+func (s *subscription) send(ctx context.Context, ev *nostr.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	select {
+	case s.ch <- ev:
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (s *subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.ch)
+}
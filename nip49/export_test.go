@@ -0,0 +1,6 @@
+package nip49
+
+var (
+	EncryptWithRand = encrypt
+	Normalize       = normalize
+)
@@ -0,0 +1,150 @@
+// Package nip49 encrypts private keys with a password into ncryptsec strings.
+//
+// See https://github.com/nostr-protocol/nips/blob/master/49.md
+package nip49
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nip19"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+const Prefix = "ncryptsec"
+
+const version = 0x02
+
+// MaxLogN is the largest accepted log_n. Scrypt with r=8 needs 128*8*2^logN bytes, that is
+// 2^logN KiB, of memory: 64 MiB at log_n 16 and 4 GiB at MaxLogN. Use DecryptMaxLogN with a
+// lower limit to decrypt ncryptsec strings from untrusted sources.
+const MaxLogN = 22
+
+// KeySecurity records how the private key was handled before it was encrypted.
+type KeySecurity byte
+
+const (
+	// KeyKnownInsecure means the key has been handled insecurely, e.g. stored unencrypted.
+	KeyKnownInsecure KeySecurity = 0x00
+	// KeyNotKnownInsecure means the key has not been known to be handled insecurely.
+	KeyNotKnownInsecure KeySecurity = 0x01
+	// KeyUnknown means the client does not track this information.
+	KeyUnknown KeySecurity = 0x02
+)
+
+// version, log_n, salt, nonce, key security, ciphertext with tag
+const encodedLen = 1 + 1 + 16 + chacha20poly1305.NonceSizeX + 1 + 32 + chacha20poly1305.Overhead
+
+var (
+	ErrUnknownVersion     = errors.New("unknown version")
+	ErrInvalidKeySecurity = errors.New("invalid key security byte")
+	ErrInvalidLength      = errors.New("invalid length")
+	ErrInvalidLogN        = errors.New("invalid log_n")
+	// ErrDecryptionFailed is returned for a wrong password or corrupted data.
+	ErrDecryptionFailed = errors.New("decryption failed")
+)
+
+// Encrypt encrypts the private key with the password. The cost of the key derivation is 2^logN,
+// the spec recommends at least 16. logN must not be larger than MaxLogN.
+func Encrypt(privkey []byte, password string, logN uint8, keySecurity KeySecurity) (string, error) {
+	return encrypt(rand.Reader, privkey, password, logN, keySecurity)
+}
+
+func encrypt(r io.Reader, privkey []byte, password string, logN uint8, keySecurity KeySecurity) (string, error) {
+	var (
+		data = make([]byte, 2+16+chacha20poly1305.NonceSizeX+1, encodedLen)
+		salt = data[2:18]
+		key  []byte
+		err  error
+	)
+	if len(privkey) != 32 {
+		return "", nip44.ErrInvalidPrivateKey
+	}
+	if keySecurity > KeyUnknown {
+		return "", ErrInvalidKeySecurity
+	}
+	if logN > MaxLogN {
+		return "", ErrInvalidLogN
+	}
+	data[0] = version
+	data[1] = logN
+	if _, err = io.ReadFull(r, data[2:2+16+chacha20poly1305.NonceSizeX]); err != nil {
+		return "", err
+	}
+	nonce := data[18 : 18+chacha20poly1305.NonceSizeX]
+	data[len(data)-1] = byte(keySecurity)
+	if key, err = deriveKey(password, salt, logN); err != nil {
+		return "", err
+	}
+	defer clear(key)
+	aead, _ := chacha20poly1305.NewX(key)
+	data = aead.Seal(data, nonce, privkey, []byte{byte(keySecurity)})
+	return nip19.EncodeBech32(Prefix, data), nil
+}
+
+// Decrypt returns the private key and its key security byte. It accepts a log_n of up to
+// MaxLogN, see DecryptMaxLogN.
+func Decrypt(ncryptsec string, password string) ([]byte, KeySecurity, error) {
+	return DecryptMaxLogN(ncryptsec, password, MaxLogN)
+}
+
+// DecryptMaxLogN is like Decrypt but fails with ErrInvalidLogN if the log_n of the ncryptsec
+// is larger than maxLogN. maxLogN can not raise the limit above MaxLogN.
+func DecryptMaxLogN(ncryptsec string, password string, maxLogN uint8) ([]byte, KeySecurity, error) {
+	var (
+		prefix  string
+		data    []byte
+		key     []byte
+		privkey []byte
+		err     error
+	)
+	if prefix, data, err = nip19.DecodeBech32(ncryptsec); err != nil {
+		return nil, 0, err
+	}
+	if prefix != Prefix {
+		return nil, 0, fmt.Errorf("%w: %s", nip19.ErrUnknownPrefix, prefix)
+	}
+	if len(data) != encodedLen {
+		return nil, 0, ErrInvalidLength
+	}
+	if data[0] != version {
+		return nil, 0, ErrUnknownVersion
+	}
+	var (
+		logN        = data[1]
+		salt        = data[2:18]
+		nonce       = data[18 : 18+chacha20poly1305.NonceSizeX]
+		keySecurity = KeySecurity(data[18+chacha20poly1305.NonceSizeX])
+		ciphertext  = data[18+chacha20poly1305.NonceSizeX+1:]
+	)
+	if keySecurity > KeyUnknown {
+		return nil, 0, ErrInvalidKeySecurity
+	}
+	if logN > min(maxLogN, MaxLogN) {
+		return nil, 0, ErrInvalidLogN
+	}
+	if key, err = deriveKey(password, salt, logN); err != nil {
+		return nil, 0, err
+	}
+	defer clear(key)
+	aead, _ := chacha20poly1305.NewX(key)
+	if privkey, err = aead.Open(nil, nonce, ciphertext, []byte{byte(keySecurity)}); err != nil {
+		return nil, 0, ErrDecryptionFailed
+	}
+	return privkey, keySecurity, nil
+}
+
+func deriveKey(password string, salt []byte, logN uint8) ([]byte, error) {
+	return scrypt.Key(normalize(password), salt, 1<<logN, 8, 1, 32)
+}
+
+// normalize returns the password in unicode normalization form NFKC so the same password
+// typed on different systems derives the same key.
+func normalize(password string) []byte {
+	return norm.NFKC.Bytes([]byte(password))
+}
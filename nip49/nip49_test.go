@@ -0,0 +1,138 @@
+package nip49_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nip19"
+	"github.com/ekzyis/nip44/nip49"
+	"github.com/stretchr/testify/assert"
+)
+
+// test vectors from https://github.com/nostr-protocol/nips/blob/master/49.md
+const (
+	ncryptsec = "ncryptsec1qgg9947rlpvqu76pj5ecreduf9jxhselq2nae2kghhvd5g7dgjtcxfqtd67p9m0w57lspw8gsq6yphnm8623nsl8xn9j4jdzz84zm3frztj3z7s35vpzmqf6ksu8r89qk5z2zxfmu5gv8th8wclt0h4p"
+	password  = "nostr"
+	privkey   = "3501454135014541350145413501453fefb02227e449e57cf4d3a3ce05378683"
+)
+
+func TestDecrypt(t *testing.T) {
+	var (
+		actual      []byte
+		keySecurity nip49.KeySecurity
+		err         error
+	)
+	actual, keySecurity, err = nip49.Decrypt(ncryptsec, password)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, privkey, hex.EncodeToString(actual))
+		assert.Equal(t, nip49.KeyKnownInsecure, keySecurity)
+	}
+	_, _, err = nip49.Decrypt(ncryptsec, "nostr2")
+	assert.ErrorIs(t, err, nip49.ErrDecryptionFailed)
+}
+
+func TestDecryptNormalizedPassword(t *testing.T) {
+	var (
+		// the password example of the spec, ÅΩẛ̣ which is normalized to ÅΩṩ
+		password = string([]byte{0xe2, 0x84, 0xab, 0xe2, 0x84, 0xa6, 0xe1, 0xba, 0x9b, 0xcc, 0xa3})
+		// encrypted with log_n 16 by an independent implementation with the normalized password
+		ncryptsec   = "ncryptsec1qggqqqgzqvzq2ps8pqys5zcvp58q7yq3zgf3g9gkzuvpjxsmrsw3u8eqyy3zxfp9ycnszsy7z3my8k39jgcrh5m2dprcghg3zwuh6yntzzkkn3p30vd6ws7kxdeqt2k7pkrywnkkq8988835c5s9wns6"
+		actual      []byte
+		keySecurity nip49.KeySecurity
+		err         error
+	)
+	actual, keySecurity, err = nip49.Decrypt(ncryptsec, password)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, privkey, hex.EncodeToString(actual))
+		assert.Equal(t, nip49.KeyNotKnownInsecure, keySecurity)
+	}
+	// the normalized password derives the same key
+	_, _, err = nip49.Decrypt(ncryptsec, string([]byte{0xc3, 0x85, 0xce, 0xa9, 0xe1, 0xb9, 0xa9}))
+	assert.NoErrorf(t, err, "decryption failed: %v", err)
+}
+
+func TestCrypt(t *testing.T) {
+	var (
+		sk, _       = hex.DecodeString(privkey)
+		encrypted   string
+		decrypted   []byte
+		keySecurity nip49.KeySecurity
+		err         error
+	)
+	// log_n 1 to keep the test fast
+	encrypted, err = nip49.Encrypt(sk, password, 1, nip49.KeyNotKnownInsecure)
+	if ok := assert.NoErrorf(t, err, "encryption failed: %v", err); !ok {
+		return
+	}
+	assert.Len(t, encrypted, 162)
+	decrypted, keySecurity, err = nip49.Decrypt(encrypted, password)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, sk, decrypted)
+		assert.Equal(t, nip49.KeyNotKnownInsecure, keySecurity)
+	}
+}
+
+func TestEncryptDeterministic(t *testing.T) {
+	var (
+		sk, _    = hex.DecodeString(privkey)
+		rand     = bytes.Repeat([]byte{0x01}, 16+24)
+		actual   string
+		expected string
+		err      error
+	)
+	expected, err = nip49.EncryptWithRand(bytes.NewReader(rand), sk, password, 1, nip49.KeyKnownInsecure)
+	if ok := assert.NoErrorf(t, err, "encryption failed: %v", err); !ok {
+		return
+	}
+	actual, err = nip49.EncryptWithRand(bytes.NewReader(rand), sk, password, 1, nip49.KeyKnownInsecure)
+	if ok := assert.NoErrorf(t, err, "encryption failed: %v", err); !ok {
+		return
+	}
+	assert.Equal(t, expected, actual)
+	// the key security byte is authenticated
+	actual, err = nip49.EncryptWithRand(bytes.NewReader(rand), sk, password, 1, nip49.KeyUnknown)
+	if ok := assert.NoErrorf(t, err, "encryption failed: %v", err); !ok {
+		return
+	}
+	assert.NotEqual(t, expected, actual)
+}
+
+func TestEncryptFail(t *testing.T) {
+	var err error
+	_, err = nip49.Encrypt(make([]byte, 31), password, 1, nip49.KeyUnknown)
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+	_, err = nip49.Encrypt(make([]byte, 32), password, 1, 0x03)
+	assert.ErrorIs(t, err, nip49.ErrInvalidKeySecurity)
+	_, err = nip49.Encrypt(make([]byte, 32), password, nip49.MaxLogN+1, nip49.KeyUnknown)
+	assert.ErrorIs(t, err, nip49.ErrInvalidLogN)
+}
+
+func TestDecryptFail(t *testing.T) {
+	var (
+		data []byte
+		err  error
+	)
+	if _, data, err = nip19.DecodeBech32(ncryptsec); err != nil {
+		t.Fatalf("decoding failed: %v", err)
+	}
+	for _, logN := range []byte{nip49.MaxLogN + 1, 45, 0xff} {
+		data[1] = logN
+		_, _, err = nip49.Decrypt(nip19.EncodeBech32(nip49.Prefix, data), password)
+		assert.ErrorIs(t, err, nip49.ErrInvalidLogN)
+	}
+	// callers can lower the limit for untrusted input, the spec vector uses log_n 16
+	_, _, err = nip49.DecryptMaxLogN(ncryptsec, password, 15)
+	assert.ErrorIs(t, err, nip49.ErrInvalidLogN)
+	_, _, err = nip49.DecryptMaxLogN(ncryptsec, password, 16)
+	assert.NoErrorf(t, err, "decryption failed: %v", err)
+}
+
+func TestNormalize(t *testing.T) {
+	var (
+		input    = string([]byte{0xe2, 0x84, 0xab, 0xe2, 0x84, 0xa6, 0xe1, 0xba, 0x9b, 0xcc, 0xa3})
+		expected = []byte{0xc3, 0x85, 0xce, 0xa9, 0xe1, 0xb9, 0xa9}
+	)
+	assert.Equal(t, expected, nip49.Normalize(input))
+}
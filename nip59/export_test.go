@@ -0,0 +1,7 @@
+package nip59
+
+import "time"
+
+func SetNow(f func() time.Time) {
+	now = f
+}
@@ -0,0 +1,176 @@
+// Package nip59 implements gift wraps: a rumor (unsigned event) is sealed (kind 13) by its
+// author and then wrapped (kind 1059) with an ephemeral key for the recipient.
+//
+// See https://github.com/nostr-protocol/nips/blob/master/59.md
+package nip59
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nostr"
+)
+
+const (
+	KindSeal     = 13
+	KindGiftWrap = 1059
+)
+
+// created_at of seals and gift wraps is randomized up to two days into the past
+// to not leak when the rumor was created.
+const maxTimestampTweak = 2 * 24 * 60 * 60
+
+var (
+	ErrNotGiftWrap      = errors.New("event is not a gift wrap")
+	ErrNotSeal          = errors.New("event is not a seal")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrSenderMismatch   = errors.New("rumor author does not match seal author")
+	ErrInvalidRumorID   = errors.New("invalid rumor id")
+	ErrSealTags         = errors.New("seal must not have tags")
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// WrapRumor seals the rumor with the sender key and gift wraps the seal for the recipient.
+// The rumor's public key and id are set if they are missing and nil tags are set to empty tags.
+func WrapRumor(rumor *nostr.Event, senderKey []byte, recipientPub string) (*nostr.Event, error) {
+	var (
+		senderPub    string
+		ephemeral    *secp256k1.PrivateKey
+		ephemeralKey []byte
+		seal         *nostr.Event
+		giftWrap     *nostr.Event
+		err          error
+	)
+	if senderPub, err = nostr.GetPublicKey(senderKey); err != nil {
+		return nil, err
+	}
+	if rumor.PubKey == "" {
+		rumor.PubKey = senderPub
+	}
+	if rumor.PubKey != senderPub {
+		return nil, nostr.ErrNotAuthor
+	}
+	// other implementations reject "tags":null
+	if rumor.Tags == nil {
+		rumor.Tags = [][]string{}
+	}
+	rumor.ID = rumor.ComputeID()
+	rumor.Sig = ""
+	if seal, err = wrap(rumor, KindSeal, senderKey, recipientPub, nil); err != nil {
+		return nil, err
+	}
+	if ephemeral, err = secp256k1.GeneratePrivateKey(); err != nil {
+		return nil, err
+	}
+	defer ephemeral.Zero()
+	ephemeralKey = ephemeral.Serialize()
+	defer clear(ephemeralKey)
+	if giftWrap, err = wrap(seal, KindGiftWrap, ephemeralKey, recipientPub, [][]string{{"p", recipientPub}}); err != nil {
+		return nil, err
+	}
+	return giftWrap, nil
+}
+
+// wrap returns a signed event of the given kind with the encrypted event as content.
+func wrap(ev *nostr.Event, kind int, privkey []byte, recipientPub string, tags [][]string) (*nostr.Event, error) {
+	var (
+		key       nip44.ConversationKey
+		plaintext []byte
+		content   string
+		createdAt int64
+		err       error
+	)
+	if plaintext, err = json.Marshal(ev); err != nil {
+		return nil, err
+	}
+	if key, err = nostr.ConversationKey(privkey, recipientPub); err != nil {
+		return nil, err
+	}
+	defer key.Wipe()
+	if content, err = key.Encrypt(string(plaintext)); err != nil {
+		return nil, err
+	}
+	if createdAt, err = randomTimestamp(); err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = [][]string{}
+	}
+	w := &nostr.Event{CreatedAt: createdAt, Kind: kind, Tags: tags, Content: content}
+	if err = w.Sign(privkey); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func randomTimestamp() (int64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return now().Unix() - int64(binary.BigEndian.Uint64(b[:])%maxTimestampTweak), nil
+}
+
+// Unwrap verifies and decrypts a gift wrap and its seal. It returns the rumor and the
+// public key of its authenticated sender.
+func Unwrap(giftWrap *nostr.Event, recipientKey []byte) (*nostr.Event, string, error) {
+	var (
+		seal  *nostr.Event
+		rumor *nostr.Event
+		err   error
+	)
+	if giftWrap.Kind != KindGiftWrap {
+		return nil, "", ErrNotGiftWrap
+	}
+	if seal, err = unwrap(giftWrap, recipientKey); err != nil {
+		return nil, "", err
+	}
+	if seal.Kind != KindSeal {
+		return nil, "", ErrNotSeal
+	}
+	if len(seal.Tags) > 0 {
+		return nil, "", ErrSealTags
+	}
+	if rumor, err = unwrap(seal, recipientKey); err != nil {
+		return nil, "", err
+	}
+	// the seal is signed by the sender, so the rumor must be authored by the same key
+	if rumor.PubKey != seal.PubKey {
+		return nil, "", ErrSenderMismatch
+	}
+	if rumor.ID != rumor.ComputeID() {
+		return nil, "", ErrInvalidRumorID
+	}
+	return rumor, seal.PubKey, nil
+}
+
+// unwrap verifies the signature of the event and returns the event in its encrypted content.
+func unwrap(ev *nostr.Event, recipientKey []byte) (*nostr.Event, error) {
+	var (
+		key       nip44.ConversationKey
+		plaintext string
+		inner     nostr.Event
+		err       error
+	)
+	if !ev.Verify() {
+		return nil, ErrInvalidSignature
+	}
+	if key, err = nostr.ConversationKey(recipientKey, ev.PubKey); err != nil {
+		return nil, err
+	}
+	defer key.Wipe()
+	if plaintext, err = key.Decrypt(ev.Content); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal([]byte(plaintext), &inner); err != nil {
+		return nil, err
+	}
+	return &inner, nil
+}
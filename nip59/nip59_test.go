@@ -0,0 +1,161 @@
+package nip59_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nip59"
+	"github.com/ekzyis/nip44/nostr"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	sk1, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	sk2, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000002")
+	sk3, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000003")
+	sk4, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000004")
+	pub1   = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	pub2   = "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+)
+
+func newRumor() *nostr.Event {
+	return &nostr.Event{
+		CreatedAt: 1700000000,
+		Kind:      14,
+		Tags:      [][]string{{"p", pub2}},
+		Content:   "hola que tal",
+	}
+}
+
+// encryptEvent is a manual seal or gift wrap construction to test forged layers.
+func encryptEvent(t *testing.T, ev *nostr.Event, kind int, privkey []byte, recipientPub string, tags [][]string) *nostr.Event {
+	var (
+		plaintext []byte
+		key       nip44.ConversationKey
+		w         = &nostr.Event{CreatedAt: 1700000000, Kind: kind, Tags: tags}
+		err       error
+	)
+	if plaintext, err = json.Marshal(ev); err != nil {
+		t.Fatal(err)
+	}
+	if key, err = nostr.ConversationKey(privkey, recipientPub); err != nil {
+		t.Fatal(err)
+	}
+	if w.Content, err = key.Encrypt(string(plaintext)); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Sign(privkey); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestWrapUnwrap(t *testing.T) {
+	var (
+		now      = time.Unix(1800000000, 0)
+		rumor    = newRumor()
+		giftWrap *nostr.Event
+		actual   *nostr.Event
+		sender   string
+		err      error
+	)
+	nip59.SetNow(func() time.Time { return now })
+	defer nip59.SetNow(time.Now)
+	giftWrap, err = nip59.WrapRumor(rumor, sk1, pub2)
+	if ok := assert.NoErrorf(t, err, "wrapping failed: %v", err); !ok {
+		return
+	}
+	assert.Equal(t, nip59.KindGiftWrap, giftWrap.Kind)
+	assert.Equal(t, [][]string{{"p", pub2}}, giftWrap.Tags)
+	assert.NotEqual(t, pub1, giftWrap.PubKey, "gift wrap must be signed by an ephemeral key")
+	assert.True(t, giftWrap.Verify())
+	assert.LessOrEqual(t, giftWrap.CreatedAt, now.Unix())
+	assert.Greater(t, giftWrap.CreatedAt, now.Add(-48*time.Hour).Unix())
+	assert.Equal(t, pub1, rumor.PubKey)
+	assert.Equal(t, rumor.ComputeID(), rumor.ID)
+
+	actual, sender, err = nip59.Unwrap(giftWrap, sk2)
+	if ok := assert.NoErrorf(t, err, "unwrapping failed: %v", err); !ok {
+		return
+	}
+	assert.Equal(t, pub1, sender)
+	assert.Equal(t, rumor, actual)
+	assert.Empty(t, actual.Sig)
+}
+
+func TestUnwrapFail(t *testing.T) {
+	var (
+		giftWrap, _ = nip59.WrapRumor(newRumor(), sk1, pub2)
+		err         error
+	)
+	_, _, err = nip59.Unwrap(giftWrap, sk3)
+	assert.ErrorIs(t, err, nip44.ErrInvalidMAC)
+
+	tampered := *giftWrap
+	tampered.CreatedAt++
+	_, _, err = nip59.Unwrap(&tampered, sk2)
+	assert.ErrorIs(t, err, nip59.ErrInvalidSignature)
+
+	tampered = *giftWrap
+	tampered.Kind = 1
+	_, _, err = nip59.Unwrap(&tampered, sk2)
+	assert.ErrorIs(t, err, nip59.ErrNotGiftWrap)
+}
+
+func TestWrapNilTags(t *testing.T) {
+	var (
+		rumor    = newRumor()
+		giftWrap *nostr.Event
+		actual   *nostr.Event
+		b        []byte
+		err      error
+	)
+	rumor.Tags = nil
+	giftWrap, err = nip59.WrapRumor(rumor, sk1, pub2)
+	if ok := assert.NoErrorf(t, err, "wrapping failed: %v", err); !ok {
+		return
+	}
+	actual, _, err = nip59.Unwrap(giftWrap, sk2)
+	if ok := assert.NoErrorf(t, err, "unwrapping failed: %v", err); !ok {
+		return
+	}
+	if b, err = json.Marshal(actual); assert.NoError(t, err) {
+		assert.Contains(t, string(b), `"tags":[]`)
+	}
+	assert.Equal(t, rumor.ID, actual.ID)
+}
+
+func TestUnwrapForgedSender(t *testing.T) {
+	var (
+		rumor = newRumor()
+		err   error
+	)
+	// sk3 seals a rumor which claims to be authored by pub1
+	rumor.PubKey = pub1
+	rumor.ID = rumor.ComputeID()
+	seal := encryptEvent(t, rumor, nip59.KindSeal, sk3, pub2, [][]string{})
+	giftWrap := encryptEvent(t, seal, nip59.KindGiftWrap, sk4, pub2, [][]string{{"p", pub2}})
+	_, _, err = nip59.Unwrap(giftWrap, sk2)
+	assert.ErrorIs(t, err, nip59.ErrSenderMismatch)
+
+	// seals must not have tags
+	seal = encryptEvent(t, rumor, nip59.KindSeal, sk1, pub2, [][]string{{"p", pub2}})
+	giftWrap = encryptEvent(t, seal, nip59.KindGiftWrap, sk4, pub2, [][]string{{"p", pub2}})
+	_, _, err = nip59.Unwrap(giftWrap, sk2)
+	assert.ErrorIs(t, err, nip59.ErrSealTags)
+
+	// the rumor id does not match its content
+	rumor.Content = "tampered"
+	seal = encryptEvent(t, rumor, nip59.KindSeal, sk1, pub2, [][]string{})
+	giftWrap = encryptEvent(t, seal, nip59.KindGiftWrap, sk4, pub2, [][]string{{"p", pub2}})
+	_, _, err = nip59.Unwrap(giftWrap, sk2)
+	assert.ErrorIs(t, err, nip59.ErrInvalidRumorID)
+
+	// the gift wrap does not contain a seal
+	giftWrap = encryptEvent(t, rumor, nip59.KindGiftWrap, sk4, pub2, [][]string{{"p", pub2}})
+	_, _, err = nip59.Unwrap(giftWrap, sk2)
+	assert.ErrorIs(t, err, nip59.ErrNotSeal)
+}
@@ -0,0 +1,91 @@
+package nostr
+
+import (
+	"errors"
+
+	"github.com/ekzyis/nip44"
+)
+
+var (
+	ErrNoCounterparty = errors.New("event has no p tag")
+	ErrNotAuthor      = errors.New("private key does not belong to event author")
+)
+
+// EncryptEventContent sets the content of an event to the encrypted plaintext. The event
+// is authored by the owner of the private key and addressed to the first p tag.
+func EncryptEventContent(ev *Event, privkey []byte, plaintext string, options ...nip44.Option) error {
+	var (
+		pubkey  string
+		peer    string
+		key     nip44.ConversationKey
+		payload string
+		err     error
+	)
+	if pubkey, err = GetPublicKey(privkey); err != nil {
+		return err
+	}
+	if ev.PubKey == "" {
+		ev.PubKey = pubkey
+	}
+	if ev.PubKey != pubkey {
+		return ErrNotAuthor
+	}
+	if peer, err = counterparty(ev, pubkey); err != nil {
+		return err
+	}
+	if key, err = ConversationKey(privkey, peer); err != nil {
+		return err
+	}
+	defer key.Wipe()
+	if payload, err = key.Encrypt(plaintext, options...); err != nil {
+		return err
+	}
+	ev.Content = payload
+	return nil
+}
+
+// DecryptEventContent decrypts the content of an event either as its author or as recipient.
+func DecryptEventContent(ev *Event, privkey []byte) (string, error) {
+	var (
+		pubkey string
+		peer   string
+		key    nip44.ConversationKey
+		err    error
+	)
+	if pubkey, err = GetPublicKey(privkey); err != nil {
+		return "", err
+	}
+	if peer, err = counterparty(ev, pubkey); err != nil {
+		return "", err
+	}
+	if key, err = ConversationKey(privkey, peer); err != nil {
+		return "", err
+	}
+	defer key.Wipe()
+	return key.Decrypt(ev.Content)
+}
+
+// counterparty returns the public key of the other party: the author, unless that is us,
+// in which case it is the recipient in the first p tag.
+func counterparty(ev *Event, pubkey string) (string, error) {
+	if ev.PubKey != pubkey {
+		return ev.PubKey, nil
+	}
+	if p, ok := ev.Tag("p"); ok {
+		return p, nil
+	}
+	return "", ErrNoCounterparty
+}
+
+// ConversationKey returns the conversation key between a private key and a hex encoded
+// x-only public key.
+func ConversationKey(privkey []byte, pubkey string) (nip44.ConversationKey, error) {
+	var (
+		pub []byte
+		err error
+	)
+	if pub, err = parsePublicKey(pubkey); err != nil {
+		return nip44.ConversationKey{}, err
+	}
+	return nip44.NewConversationKey(privkey, pub)
+}
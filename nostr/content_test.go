@@ -0,0 +1,86 @@
+package nostr_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nostr"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	sk1, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	sk2, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000002")
+	sk3, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000003")
+	pub1   = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	pub2   = "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+)
+
+func TestGetPublicKey(t *testing.T) {
+	var (
+		pubkey string
+		err    error
+	)
+	pubkey, err = nostr.GetPublicKey(sk1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, pub1, pubkey)
+	}
+	_, err = nostr.GetPublicKey(make([]byte, 32))
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+}
+
+func TestDecryptEventContent(t *testing.T) {
+	var (
+		ev = &nostr.Event{
+			PubKey:  pub1,
+			Kind:    14,
+			Tags:    [][]string{{"p", pub2}},
+			Content: "AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb",
+		}
+		plaintext string
+		err       error
+	)
+	// as recipient
+	plaintext, err = nostr.DecryptEventContent(ev, sk2)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, "a", plaintext)
+	}
+	// as author
+	plaintext, err = nostr.DecryptEventContent(ev, sk1)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, "a", plaintext)
+	}
+	// as someone else
+	_, err = nostr.DecryptEventContent(ev, sk3)
+	assert.ErrorIs(t, err, nip44.ErrInvalidMAC)
+}
+
+func TestEncryptEventContent(t *testing.T) {
+	var (
+		salt, _   = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+		ev        = &nostr.Event{Kind: 14, Tags: [][]string{{"p", pub2}}}
+		plaintext string
+		err       error
+	)
+	err = nostr.EncryptEventContent(ev, sk1, "a", nip44.WithSalt(salt))
+	if ok := assert.NoErrorf(t, err, "encryption failed: %v", err); !ok {
+		return
+	}
+	assert.Equal(t, pub1, ev.PubKey)
+	assert.Equal(t, "AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb", ev.Content)
+	plaintext, err = nostr.DecryptEventContent(ev, sk2)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, "a", plaintext)
+	}
+}
+
+func TestEncryptEventContentFail(t *testing.T) {
+	var err error
+	err = nostr.EncryptEventContent(&nostr.Event{Kind: 14}, sk1, "a")
+	assert.ErrorIs(t, err, nostr.ErrNoCounterparty)
+	err = nostr.EncryptEventContent(&nostr.Event{PubKey: pub2, Tags: [][]string{{"p", pub1}}}, sk1, "a")
+	assert.ErrorIs(t, err, nostr.ErrNotAuthor)
+	err = nostr.EncryptEventContent(&nostr.Event{Tags: [][]string{{"p", "xyz"}}}, sk1, "a")
+	assert.ErrorIs(t, err, nip44.ErrInvalidPublicKey)
+}
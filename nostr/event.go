@@ -0,0 +1,133 @@
+// Package nostr implements the parts of nostr events needed to use NIP-44 encrypted content.
+package nostr
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+type Event struct {
+	ID        string     `json:"id"`
+	PubKey    string     `json:"pubkey"`
+	CreatedAt int64      `json:"created_at"`
+	Kind      int        `json:"kind"`
+	Tags      [][]string `json:"tags"`
+	Content   string     `json:"content"`
+	Sig       string     `json:"sig,omitempty"`
+}
+
+// Serialize returns the NIP-01 serialization of the event which is hashed to get its id.
+func (e *Event) Serialize() []byte {
+	var b []byte
+	b = append(b, "[0,"...)
+	b = appendString(b, e.PubKey)
+	b = append(b, ',')
+	b = strconv.AppendInt(b, e.CreatedAt, 10)
+	b = append(b, ',')
+	b = strconv.AppendInt(b, int64(e.Kind), 10)
+	b = append(b, ",["...)
+	for i, tag := range e.Tags {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, '[')
+		for j, s := range tag {
+			if j > 0 {
+				b = append(b, ',')
+			}
+			b = appendString(b, s)
+		}
+		b = append(b, ']')
+	}
+	b = append(b, "],"...)
+	b = appendString(b, e.Content)
+	b = append(b, ']')
+	return b
+}
+
+// ComputeID returns the hex encoded sha256 hash of the serialized event.
+func (e *Event) ComputeID() string {
+	h := sha256.Sum256(e.Serialize())
+	return hex.EncodeToString(h[:])
+}
+
+// Sign sets the public key, id and signature of the event.
+func (e *Event) Sign(privkey []byte) error {
+	var (
+		pubkey string
+		id     []byte
+		sig    []byte
+		err    error
+	)
+	if pubkey, err = GetPublicKey(privkey); err != nil {
+		return err
+	}
+	e.PubKey = pubkey
+	e.ID = e.ComputeID()
+	id, _ = hex.DecodeString(e.ID)
+	if sig, err = Sign(privkey, id); err != nil {
+		return err
+	}
+	e.Sig = hex.EncodeToString(sig)
+	return nil
+}
+
+// Verify checks that the id matches the content of the event and that it is signed by its author.
+func (e *Event) Verify() bool {
+	var (
+		pubkey []byte
+		id     []byte
+		sig    []byte
+		err    error
+	)
+	if e.ID != e.ComputeID() {
+		return false
+	}
+	if pubkey, err = hex.DecodeString(e.PubKey); err != nil {
+		return false
+	}
+	if id, err = hex.DecodeString(e.ID); err != nil {
+		return false
+	}
+	if sig, err = hex.DecodeString(e.Sig); err != nil {
+		return false
+	}
+	return Verify(pubkey, id, sig)
+}
+
+// Tag returns the value of the first tag with the given name.
+func (e *Event) Tag(name string) (string, bool) {
+	for _, tag := range e.Tags {
+		if len(tag) >= 2 && tag[0] == name {
+			return tag[1], true
+		}
+	}
+	return "", false
+}
+
+// appendString appends s as JSON string with the escaping rules of NIP-01.
+func appendString(b []byte, s string) []byte {
+	b = append(b, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			b = append(b, '\\', '"')
+		case '\\':
+			b = append(b, '\\', '\\')
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		case '\b':
+			b = append(b, '\\', 'b')
+		case '\f':
+			b = append(b, '\\', 'f')
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '"')
+}
@@ -0,0 +1,65 @@
+package nostr_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ekzyis/nip44/nostr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSerialize(t *testing.T) {
+	var (
+		ev = &nostr.Event{
+			PubKey:    "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",
+			CreatedAt: 1700000000,
+			Kind:      14,
+			Tags:      [][]string{{"p", "c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"}, {"subject", "<b>&"}},
+			Content:   "\"quoted\"\\ \n\r\t\b\f ünïcödé 🍕  ",
+		}
+		expected = `[0,"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798",1700000000,14,` +
+			`[["p","c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"],["subject","<b>&"]],` +
+			`"\"quoted\"\\ \n\r\t\b\f ünïcödé 🍕 ` + " " + `"]`
+		hash = sha256.Sum256([]byte(expected))
+	)
+	assert.Equal(t, expected, string(ev.Serialize()))
+	assert.Equal(t, hex.EncodeToString(hash[:]), ev.ComputeID())
+}
+
+func TestSerializeEmpty(t *testing.T) {
+	ev := &nostr.Event{Kind: 1}
+	assert.Equal(t, `[0,"",0,1,[],""]`, string(ev.Serialize()))
+}
+
+func TestTag(t *testing.T) {
+	var (
+		ev = &nostr.Event{Tags: [][]string{{"e"}, {"p", "a"}, {"p", "b"}}}
+		v  string
+		ok bool
+	)
+	v, ok = ev.Tag("p")
+	assert.True(t, ok)
+	assert.Equal(t, "a", v)
+	_, ok = ev.Tag("e")
+	assert.False(t, ok)
+}
+
+func TestSignVerify(t *testing.T) {
+	var (
+		sk, _ = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000003")
+		ev    = &nostr.Event{CreatedAt: 1700000000, Kind: 1, Content: "hello"}
+		err   error
+	)
+	err = ev.Sign(sk)
+	if ok := assert.NoErrorf(t, err, "signing failed: %v", err); !ok {
+		return
+	}
+	assert.Equal(t, "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9", ev.PubKey)
+	assert.Equal(t, ev.ComputeID(), ev.ID)
+	assert.True(t, ev.Verify())
+	ev.Content = "tampered"
+	assert.False(t, ev.Verify())
+	ev.ID = ev.ComputeID()
+	assert.False(t, ev.Verify())
+}
@@ -0,0 +1,3 @@
+package nostr
+
+var SignAux = sign
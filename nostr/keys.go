@@ -0,0 +1,41 @@
+package nostr
+
+import (
+	"encoding/hex"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/ekzyis/nip44"
+)
+
+// GetPublicKey returns the hex encoded x-only public key of a private key.
+func GetPublicKey(privkey []byte) (string, error) {
+	var (
+		sk  *secp256k1.PrivateKey
+		err error
+	)
+	if sk, err = parsePrivateKey(privkey); err != nil {
+		return "", err
+	}
+	defer sk.Zero()
+	return hex.EncodeToString(sk.PubKey().SerializeCompressed()[1:]), nil
+}
+
+func parsePrivateKey(privkey []byte) (*secp256k1.PrivateKey, error) {
+	var s secp256k1.ModNScalar
+	if len(privkey) != 32 || s.SetByteSlice(privkey) || s.IsZero() {
+		return nil, nip44.ErrInvalidPrivateKey
+	}
+	return secp256k1.NewPrivateKey(&s), nil
+}
+
+// parsePublicKey decodes a hex encoded x-only public key.
+func parsePublicKey(pubkey string) ([]byte, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if b, err = hex.DecodeString(pubkey); err != nil || len(b) != 32 {
+		return nil, &nip44.Error{Err: nip44.ErrInvalidPublicKey, Cause: err}
+	}
+	return b, nil
+}
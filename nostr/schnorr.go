@@ -0,0 +1,55 @@
+package nostr
+
+import (
+	"crypto/rand"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// BIP-340 Schnorr signatures as used by nostr. The schnorr package of the secp256k1 module
+// implements a different scheme (EC-Schnorr-DCRv0), so the BIP-340 implementation of btcec
+// is used which is built on the same module.
+// See https://github.com/bitcoin/bips/blob/master/bip-0340.mediawiki
+
+// Sign returns the BIP-340 signature of the event id using fresh auxiliary randomness.
+func Sign(privkey []byte, eventID []byte) ([]byte, error) {
+	var auxRand [32]byte
+	if _, err := rand.Read(auxRand[:]); err != nil {
+		return nil, err
+	}
+	return sign(privkey, eventID, auxRand)
+}
+
+func sign(privkey []byte, msg []byte, auxRand [32]byte) ([]byte, error) {
+	var (
+		sk  *secp256k1.PrivateKey
+		sig *schnorr.Signature
+		err error
+	)
+	if sk, err = parsePrivateKey(privkey); err != nil {
+		return nil, err
+	}
+	defer sk.Zero()
+	// the signature is verified before it is returned
+	if sig, err = schnorr.Sign(sk, msg, schnorr.CustomNonce(auxRand)); err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+// Verify reports whether sig is a valid BIP-340 signature of the event id by the x-only public key.
+func Verify(xonlyPub []byte, eventID []byte, sig []byte) bool {
+	var (
+		pk  *secp256k1.PublicKey
+		s   *schnorr.Signature
+		err error
+	)
+	if pk, err = schnorr.ParsePubKey(xonlyPub); err != nil {
+		return false
+	}
+	if s, err = schnorr.ParseSignature(sig); err != nil {
+		return false
+	}
+	return s.Verify(eventID, pk)
+}
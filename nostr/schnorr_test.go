@@ -0,0 +1,82 @@
+package nostr_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/ekzyis/nip44/nostr"
+	"github.com/stretchr/testify/assert"
+)
+
+// To update the test vectors, replace testdata/bip340-vectors.csv and update its checksum.
+// See https://github.com/bitcoin/bips/blob/master/bip-0340/test-vectors.csv
+const (
+	bip340VectorsPath   = "testdata/bip340-vectors.csv"
+	bip340VectorsSha256 = "34c9d1d9c3a88d524bc80778540dc43f8306ec249a7485293063c376db851c2d"
+)
+
+func loadBip340Vectors(t *testing.T) [][]string {
+	var (
+		b       []byte
+		records [][]string
+		err     error
+	)
+	if b, err = os.ReadFile(bip340VectorsPath); err != nil {
+		t.Fatalf("failed to read test vectors: %v", err)
+	}
+	if sum := sha256.Sum256(b); hex.EncodeToString(sum[:]) != bip340VectorsSha256 {
+		t.Fatalf("sha256 of %s does not match", bip340VectorsPath)
+	}
+	if records, err = csv.NewReader(bytes.NewReader(b)).ReadAll(); err != nil {
+		t.Fatalf("failed to parse test vectors: %v", err)
+	}
+	// skip header
+	return records[1:]
+}
+
+func TestBip340Vectors(t *testing.T) {
+	for _, v := range loadBip340Vectors(t) {
+		var (
+			index      = v[0]
+			sk, _      = hex.DecodeString(v[1])
+			pub, _     = hex.DecodeString(v[2])
+			auxRand, _ = hex.DecodeString(v[3])
+			msg, _     = hex.DecodeString(v[4])
+			sig, _     = hex.DecodeString(v[5])
+			valid      = v[6] == "TRUE"
+			comment    = v[7]
+		)
+		if len(msg) != 32 {
+			// nostr only signs 32-byte event ids and btcec does not support other lengths
+			continue
+		}
+		t.Run(fmt.Sprintf("%s %s", index, comment), func(t *testing.T) {
+			if len(sk) > 0 {
+				actual, err := nostr.SignAux(sk, msg, [32]byte(auxRand))
+				if assert.NoErrorf(t, err, "signing failed: %v", err) {
+					assert.Equal(t, sig, actual)
+				}
+				_, err = nostr.Sign(sk, msg)
+				assert.NoErrorf(t, err, "signing failed: %v", err)
+			}
+			assert.Equal(t, valid, nostr.Verify(pub, msg, sig))
+		})
+	}
+}
+
+func TestSignFail(t *testing.T) {
+	var (
+		msg = make([]byte, 32)
+		err error
+	)
+	_, err = nostr.Sign(make([]byte, 32), msg)
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+	_, err = nostr.Sign(make([]byte, 31), msg)
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+}
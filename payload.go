@@ -0,0 +1,77 @@
+package nip44
+
+import (
+	"encoding/base64"
+	"slices"
+)
+
+// Payload is the wire structure of an encrypted message.
+type Payload struct {
+	Version    int
+	Salt       []byte
+	Ciphertext []byte
+	MAC        []byte
+}
+
+// ParsePayload decodes and validates the structure of a payload. It does not verify the MAC.
+func ParsePayload(payload string) (*Payload, error) {
+	return parsePayload([]byte(payload))
+}
+
+func parsePayload(payload []byte) (*Payload, error) {
+	var (
+		p      = &Payload{}
+		scheme Scheme
+		err    error
+	)
+	if scheme, err = payloadScheme(payload); err != nil {
+		return nil, err
+	}
+	if _, err = decodePayload(p, nil, payload); err != nil {
+		return nil, err
+	}
+	if pc, ok := scheme.(payloadChecker); ok {
+		if err = pc.checkPayload(p, payload); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// decodePayload decodes the payload into buf, growing it if needed, and sets the fields of p
+// to slices of buf. It returns the buffer for reuse. The version and the payload length must
+// have been checked with the scheme of the payload.
+func decodePayload(p *Payload, buf []byte, payload []byte) ([]byte, error) {
+	var (
+		n    = base64.StdEncoding.DecodedLen(len(payload))
+		dLen int
+		err  error
+	)
+	buf = slices.Grow(buf[:0], n)[:n]
+	if dLen, err = base64.StdEncoding.Decode(buf, payload); err != nil {
+		return buf, &Error{Err: ErrInvalidBase64, Cause: err}
+	}
+	// version (1) + salt (32) + mac (32)
+	if dLen < 1+32+32 {
+		return buf, &Error{Err: ErrInvalidPayloadLength, Length: len(payload)}
+	}
+	p.Version = int(buf[0])
+	p.Salt = buf[1:33]
+	p.Ciphertext = buf[33 : dLen-32]
+	p.MAC = buf[dLen-32 : dLen]
+	return buf, nil
+}
+
+func (p *Payload) Encode() string {
+	return string(p.appendEncode(nil))
+}
+
+func (p *Payload) appendEncode(dst []byte) []byte {
+	var concat []byte
+	concat = make([]byte, 0, 1+len(p.Salt)+len(p.Ciphertext)+len(p.MAC))
+	concat = append(concat, byte(p.Version))
+	concat = append(concat, p.Salt...)
+	concat = append(concat, p.Ciphertext...)
+	concat = append(concat, p.MAC...)
+	return appendBase64(dst, concat)
+}
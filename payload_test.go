@@ -0,0 +1,44 @@
+package nip44_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePayload(t *testing.T) {
+	var (
+		payload = "AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb"
+		p       *nip44.Payload
+		err     error
+	)
+	p, err = nip44.ParsePayload(payload)
+	if ok := assert.NoErrorf(t, err, "payload parsing failed: %v", err); !ok {
+		return
+	}
+	assert.Equal(t, 2, p.Version)
+	assert.Equal(t, "0000000000000000000000000000000000000000000000000000000000000001", hex.EncodeToString(p.Salt))
+	assert.Len(t, p.Ciphertext, 34)
+	assert.Equal(t, "794259929a02bb06ad8e8cf709ee4ccc567e9d514cdf5781af27a3e905e55b1b", hex.EncodeToString(p.MAC))
+	assert.Equal(t, payload, p.Encode())
+}
+
+func TestParsePayloadFail(t *testing.T) {
+	for _, v := range loadVectors(t).V2.Invalid.Decrypt {
+		expected := decryptError(t, v.Note)
+		if expected == nip44.ErrInvalidMAC || expected == nip44.ErrInvalidPadding {
+			// not detectable without the key
+			continue
+		}
+		_, err := nip44.ParsePayload(v.Payload)
+		assertError(t, expected, err)
+	}
+	// newlines are skipped by the base64 decoder so the ciphertext is too short
+	// even though the length of the payload is valid
+	payload := "Av" + strings.Repeat("0", 95) + "\r\r\r\r" + strings.Repeat("0", 31)
+	_, err := nip44.ParsePayload(payload)
+	assertError(t, &nip44.Error{Err: nip44.ErrInvalidPayloadLength, Length: 132}, err)
+}
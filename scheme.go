@@ -0,0 +1,129 @@
+package nip44
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+)
+
+// DefaultVersion is the version used for encryption if no version is given.
+const DefaultVersion = 2
+
+// Scheme implements one version of the encryption. Payloads start with their version byte
+// so decryption can dispatch to the scheme registered for it.
+type Scheme interface {
+	// AppendEncrypt encrypts plaintext and appends the encoded payload to dst.
+	AppendEncrypt(dst []byte, conversationKey []byte, plaintext []byte, o EncryptOptions) ([]byte, error)
+	// AppendDecrypt decrypts the encoded payload and appends the plaintext to dst.
+	AppendDecrypt(dst []byte, conversationKey []byte, payload []byte) ([]byte, error)
+	// MinPlaintextLen and MaxPlaintextLen are the bounds of the plaintext length in bytes.
+	// Encrypt rejects plaintexts outside of them before calling AppendEncrypt.
+	MinPlaintextLen() int
+	MaxPlaintextLen() int
+	// MinPayloadLen and MaxPayloadLen are the bounds of the encoded payload length in bytes.
+	// Decrypt rejects payloads outside of them before calling AppendDecrypt.
+	MinPayloadLen() int
+	MaxPayloadLen() int
+}
+
+// cipherScheme is implemented by schemes which can use a Cipher with the conversation key
+// already set, so callers like batches can reuse one Cipher for many messages.
+type cipherScheme interface {
+	Scheme
+	appendEncryptCipher(c *Cipher, dst []byte, plaintext []byte, o EncryptOptions) ([]byte, error)
+	appendDecryptCipher(c *Cipher, dst []byte, payload []byte) ([]byte, error)
+}
+
+// payloadChecker is implemented by schemes which check the fields of decoded payloads
+// beyond the length of the encoded payload.
+type payloadChecker interface {
+	checkPayload(p *Payload, payload []byte) error
+}
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = map[int]Scheme{2: v2{}}
+)
+
+// RegisterScheme makes a scheme available for the given version. It panics if the version
+// is not a byte, the scheme is nil or a scheme is already registered for the version.
+func RegisterScheme(version int, scheme Scheme) {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	if version < 1 || version > 255 {
+		panic(fmt.Sprintf("nip44: invalid version %d", version))
+	}
+	if scheme == nil {
+		panic("nip44: scheme is nil")
+	}
+	if _, ok := schemes[version]; ok {
+		panic(fmt.Sprintf("nip44: scheme for version %d already registered", version))
+	}
+	schemes[version] = scheme
+}
+
+// LookupScheme returns the scheme registered for the version.
+func LookupScheme(version int) (Scheme, bool) {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	scheme, ok := schemes[version]
+	return scheme, ok
+}
+
+// payloadScheme returns the scheme registered for the version of the payload after checking
+// the payload length against the bounds of the scheme.
+func payloadScheme(payload []byte) (Scheme, error) {
+	var (
+		version int
+		scheme  Scheme
+		ok      bool
+		err     error
+	)
+	if version, err = payloadVersion(payload); err == nil {
+		if scheme, ok = LookupScheme(version); ok {
+			if err = checkPayloadLen(scheme, payload); err != nil {
+				return nil, err
+			}
+			return scheme, nil
+		}
+		err = &Error{Err: ErrUnknownVersion, Version: version}
+	}
+	// Payloads which are not even valid for the default scheme are reported as such, since
+	// the version of random input is meaningless. This is also the order of checks in the spec.
+	if scheme, ok = LookupScheme(DefaultVersion); ok {
+		if lenErr := checkPayloadLen(scheme, payload); lenErr != nil {
+			return nil, lenErr
+		}
+	}
+	return nil, err
+}
+
+// payloadVersion returns the version of the payload which is its first base64 encoded byte.
+func payloadVersion(payload []byte) (int, error) {
+	var version [3]byte
+	// versions with a non-base64 encoding are marked with a leading # and not supported
+	if len(payload) > 0 && payload[0] == '#' {
+		return 0, ErrUnknownVersion
+	}
+	if len(payload) < 4 {
+		return 0, &Error{Err: ErrInvalidPayloadLength, Length: len(payload)}
+	}
+	if _, err := base64.StdEncoding.Decode(version[:], payload[:4]); err != nil {
+		return 0, &Error{Err: ErrInvalidBase64, Cause: err}
+	}
+	return int(version[0]), nil
+}
+
+func checkPayloadLen(scheme Scheme, payload []byte) error {
+	if len(payload) < scheme.MinPayloadLen() || len(payload) > scheme.MaxPayloadLen() {
+		return &Error{Err: ErrInvalidPayloadLength, Length: len(payload)}
+	}
+	return nil
+}
+
+func checkPlaintextLen(scheme Scheme, plaintext []byte) error {
+	if len(plaintext) < scheme.MinPlaintextLen() || len(plaintext) > scheme.MaxPlaintextLen() {
+		return ErrInvalidPlaintextLength
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package nip44_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+// plainScheme is an insecure scheme which only prepends its version to the plaintext.
+type plainScheme struct {
+	version byte
+}
+
+func (s plainScheme) MinPlaintextLen() int { return 1 }
+func (s plainScheme) MaxPlaintextLen() int { return 100 }
+func (s plainScheme) MinPayloadLen() int   { return 4 }
+func (s plainScheme) MaxPayloadLen() int   { return 136 }
+
+func (s plainScheme) AppendEncrypt(dst []byte, conversationKey []byte, plaintext []byte, o nip44.EncryptOptions) ([]byte, error) {
+	return append(dst, base64.StdEncoding.EncodeToString(append([]byte{s.version}, plaintext...))...), nil
+}
+
+func (s plainScheme) AppendDecrypt(dst []byte, conversationKey []byte, payload []byte) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(payload))
+	if err != nil || len(decoded) < 2 {
+		return nil, errors.New("invalid payload")
+	}
+	return append(dst, decoded[1:]...), nil
+}
+
+func TestScheme(t *testing.T) {
+	var (
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		v2Payload  = "AgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABee0G5VSK0/9YypIObAtDKfYEAjD35uVkHyB0F4DwrcNaCXlCWZKaArsGrY6M9wnuTMxWfp1RTN9Xga8no+kF5Vsb"
+		payload    string
+		plaintext  string
+		err        error
+	)
+	_, err = nip44.Encrypt(convKey, "a", nip44.WithVersion(3))
+	assertError(t, &nip44.Error{Err: nip44.ErrUnknownVersion, Version: 3}, err)
+
+	nip44.RegisterScheme(3, plainScheme{version: 3})
+	defer nip44.UnregisterScheme(3)
+
+	payload, err = nip44.Encrypt(convKey, "hello", nip44.WithVersion(3))
+	if assert.NoErrorf(t, err, "encryption failed: %v", err) {
+		assert.Equal(t, "A2hlbGxv", payload)
+	}
+	plaintext, err = nip44.Decrypt(convKey, payload)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, "hello", plaintext)
+	}
+	// the length bounds of the scheme are used instead of the bounds of version 2
+	_, err = nip44.Encrypt(convKey, strings.Repeat("a", 101), nip44.WithVersion(3))
+	assert.ErrorIs(t, err, nip44.ErrInvalidPlaintextLength)
+	payload = base64.StdEncoding.EncodeToString(append([]byte{3}, strings.Repeat("a", 102)...))
+	_, err = nip44.Decrypt(convKey, payload)
+	assertError(t, &nip44.Error{Err: nip44.ErrInvalidPayloadLength, Length: 140}, err)
+	_, err = nip44.ParsePayload(payload)
+	assertError(t, &nip44.Error{Err: nip44.ErrInvalidPayloadLength, Length: 140}, err)
+	// versions with a non-base64 encoding are not handled by version 2
+	_, err = nip44.Decrypt(convKey, "#"+v2Payload[1:])
+	assertError(t, nip44.ErrUnknownVersion, err)
+	// version 2 is still the default for encryption and used for version 2 payloads
+	payload, err = nip44.Encrypt(convKey, "a")
+	if assert.NoErrorf(t, err, "encryption failed: %v", err) {
+		p, err := nip44.ParsePayload(payload)
+		if assert.NoErrorf(t, err, "parsing failed: %v", err) {
+			assert.Equal(t, 2, p.Version)
+		}
+	}
+	plaintext, err = nip44.Decrypt(convKey, v2Payload)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, "a", plaintext)
+	}
+}
+
+func TestRegisterSchemeFail(t *testing.T) {
+	assert.Panics(t, func() { nip44.RegisterScheme(2, plainScheme{version: 2}) })
+	assert.Panics(t, func() { nip44.RegisterScheme(0, plainScheme{version: 0}) })
+	assert.Panics(t, func() { nip44.RegisterScheme(256, plainScheme{version: 0}) })
+	assert.Panics(t, func() { nip44.RegisterScheme(4, nil) })
+	_, ok := nip44.LookupScheme(4)
+	assert.False(t, ok)
+}
+
+func TestLookupScheme(t *testing.T) {
+	scheme, ok := nip44.LookupScheme(nip44.DefaultVersion)
+	if assert.True(t, ok) {
+		assert.Equal(t, 1, scheme.MinPlaintextLen())
+		assert.Equal(t, 65535, scheme.MaxPlaintextLen())
+		assert.Equal(t, 132, scheme.MinPayloadLen())
+		assert.Equal(t, 87472, scheme.MaxPayloadLen())
+	}
+}
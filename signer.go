@@ -0,0 +1,76 @@
+package nip44
+
+import (
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// Signer holds a private key and performs the operations which need it, so the key can live
+// in a remote or isolated key holder like a NIP-46 bunker. Public keys of peers can be given
+// in any encoding accepted by GenerateConversationKey.
+type Signer interface {
+	// PublicKey returns the 32-byte x-only public key.
+	PublicKey() ([]byte, error)
+	ConversationKey(peerPub []byte) (ConversationKey, error)
+	Encrypt(peerPub []byte, plaintext string) (string, error)
+	Decrypt(peerPub []byte, payload string) (string, error)
+}
+
+// KeySigner is a Signer which holds the private key in memory.
+type KeySigner struct {
+	privkey [32]byte
+	pubkey  []byte
+}
+
+var _ Signer = (*KeySigner)(nil)
+
+// NewKeySigner returns a Signer for the private key. The key is copied.
+func NewKeySigner(privkey []byte) (*KeySigner, error) {
+	var (
+		s  = &KeySigner{}
+		sk secp256k1.ModNScalar
+	)
+	if len(privkey) != 32 || sk.SetByteSlice(privkey) || sk.IsZero() {
+		return nil, ErrInvalidPrivateKey
+	}
+	defer sk.Zero()
+	copy(s.privkey[:], privkey)
+	s.pubkey = secp256k1.NewPrivateKey(&sk).PubKey().SerializeCompressed()[1:]
+	return s, nil
+}
+
+func (s *KeySigner) PublicKey() ([]byte, error) {
+	return append([]byte(nil), s.pubkey...), nil
+}
+
+func (s *KeySigner) ConversationKey(peerPub []byte) (ConversationKey, error) {
+	return NewConversationKey(s.privkey[:], peerPub)
+}
+
+func (s *KeySigner) Encrypt(peerPub []byte, plaintext string) (string, error) {
+	var (
+		key ConversationKey
+		err error
+	)
+	if key, err = s.ConversationKey(peerPub); err != nil {
+		return "", err
+	}
+	defer key.Wipe()
+	return key.Encrypt(plaintext)
+}
+
+func (s *KeySigner) Decrypt(peerPub []byte, payload string) (string, error) {
+	var (
+		key ConversationKey
+		err error
+	)
+	if key, err = s.ConversationKey(peerPub); err != nil {
+		return "", err
+	}
+	defer key.Wipe()
+	return key.Decrypt(payload)
+}
+
+// Wipe overwrites the private key with zeros. The signer must not be used afterwards.
+func (s *KeySigner) Wipe() {
+	clear(s.privkey[:])
+}
@@ -0,0 +1,57 @@
+package nip44_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySigner(t *testing.T) {
+	var (
+		sk1, _   = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+		sk2, _   = hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000002")
+		expected = "c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d"
+		s1, s2   nip44.Signer
+		pub1     []byte
+		pub2     []byte
+		key      nip44.ConversationKey
+		payload  string
+		actual   string
+		err      error
+	)
+	if s1, err = nip44.NewKeySigner(sk1); err != nil {
+		t.Fatalf("creating signer failed: %v", err)
+	}
+	if s2, err = nip44.NewKeySigner(sk2); err != nil {
+		t.Fatalf("creating signer failed: %v", err)
+	}
+	pub1, err = s1.PublicKey()
+	if assert.NoErrorf(t, err, "public key failed: %v", err) {
+		assert.Equal(t, "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", hex.EncodeToString(pub1))
+	}
+	pub2, _ = s2.PublicKey()
+	key, err = s1.ConversationKey(pub2)
+	if assert.NoErrorf(t, err, "conversation key failed: %v", err) {
+		assert.Equal(t, expected, hex.EncodeToString(key[:]))
+	}
+	payload, err = s1.Encrypt(pub2, "hello")
+	if ok := assert.NoErrorf(t, err, "encryption failed: %v", err); !ok {
+		return
+	}
+	actual, err = s2.Decrypt(pub1, payload)
+	if assert.NoErrorf(t, err, "decryption failed: %v", err) {
+		assert.Equal(t, "hello", actual)
+	}
+	_, err = s1.Encrypt(pub2[1:], "hello")
+	assert.ErrorIs(t, err, nip44.ErrInvalidPublicKey)
+}
+
+func TestKeySignerFail(t *testing.T) {
+	var err error
+	_, err = nip44.NewKeySigner(make([]byte, 32))
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+	_, err = nip44.NewKeySigner(make([]byte, 31))
+	assert.ErrorIs(t, err, nip44.ErrInvalidPrivateKey)
+}
@@ -0,0 +1,213 @@
+package nip44
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Streams encrypt plaintexts of any size as a sequence of chunks. Each chunk is a standard
+// version 2 payload terminated by a newline. The plaintext of each chunk starts with a header:
+//
+//	stream id (16 bytes) | chunk index (8 bytes, big endian) | final flag (1 byte) | data
+//
+// The random stream id salts the index so chunks can not be reordered, dropped or mixed
+// between streams without detection, and the final flag detects truncation.
+
+const (
+	streamIDSize      = 16
+	streamHeaderSize  = streamIDSize + 8 + 1
+	streamChunkSize   = 0xffff - streamHeaderSize
+	streamMaxLineSize = 87472 + 1
+)
+
+type encryptWriter struct {
+	w       io.Writer
+	key     ConversationKey
+	rand    io.Reader
+	buf     []byte
+	payload []byte
+	index   uint64
+	closed  bool
+	err     error
+}
+
+// NewEncryptWriter returns a writer which encrypts everything written to it as a chunked stream
+// to w. Close must be called to write the final chunk. The Salt option is not supported since
+// every chunk needs a fresh salt.
+func NewEncryptWriter(w io.Writer, conversationKey []byte, options ...Option) (io.WriteCloser, error) {
+	var (
+		o   = applyOptions(options)
+		e   = &encryptWriter{w: w, rand: o.Rand}
+		err error
+	)
+	if o.Version != 0 && o.Version != 2 {
+		return nil, &Error{Err: ErrUnknownVersion, Version: o.Version}
+	}
+	if o.Salt != nil {
+		return nil, ErrSaltNotAllowed
+	}
+	if e.key, err = ConversationKeyFromBytes(conversationKey); err != nil {
+		return nil, err
+	}
+	e.buf = make([]byte, streamHeaderSize, streamHeaderSize+streamChunkSize)
+	if _, err = io.ReadFull(e.randReader(), e.buf[:streamIDSize]); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *encryptWriter) randReader() io.Reader {
+	if e.rand == nil {
+		return DefaultRand
+	}
+	return e.rand
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	var n int
+	if e.closed {
+		return 0, ErrStreamClosed
+	}
+	if e.err != nil {
+		return 0, e.err
+	}
+	for len(p) > 0 {
+		// a full chunk is only written once more data arrives since it may be the final one
+		if len(e.buf) == cap(e.buf) {
+			if e.err = e.flush(false); e.err != nil {
+				return n, e.err
+			}
+		}
+		m := min(len(p), cap(e.buf)-len(e.buf))
+		e.buf = append(e.buf, p[:m]...)
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close writes the final chunk and wipes the conversation key. It does not close the underlying writer.
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	defer e.key.Wipe()
+	defer clear(e.buf[:cap(e.buf)])
+	if e.err != nil {
+		return e.err
+	}
+	return e.flush(true)
+}
+
+func (e *encryptWriter) flush(final bool) error {
+	var err error
+	binary.BigEndian.PutUint64(e.buf[streamIDSize:], e.index)
+	e.buf[streamIDSize+8] = 0
+	if final {
+		e.buf[streamIDSize+8] = 1
+	}
+	if e.payload, err = AppendEncrypt(e.payload[:0], e.key[:], e.buf, WithRand(e.rand)); err != nil {
+		return err
+	}
+	e.payload = append(e.payload, '\n')
+	if _, err = e.w.Write(e.payload); err != nil {
+		return err
+	}
+	e.index++
+	e.buf = e.buf[:streamHeaderSize]
+	return nil
+}
+
+type decryptReader struct {
+	r        *bufio.Reader
+	c        *Cipher
+	streamID []byte
+	buf      []byte
+	data     []byte
+	index    uint64
+	final    bool
+	err      error
+}
+
+// NewDecryptReader returns a reader which decrypts a chunked stream created with NewEncryptWriter.
+// Reading fails with ErrStreamTruncated if the stream ends before its final chunk.
+func NewDecryptReader(r io.Reader, conversationKey []byte) (io.Reader, error) {
+	var (
+		d   = &decryptReader{r: bufio.NewReaderSize(r, streamMaxLineSize)}
+		err error
+	)
+	if d.c, err = NewCipher(conversationKey); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.data) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.final {
+			d.err = d.end()
+			continue
+		}
+		if d.err = d.next(); d.err != nil {
+			d.c.Wipe()
+		}
+	}
+	n := copy(p, d.data)
+	d.data = d.data[n:]
+	return n, nil
+}
+
+// next decrypts the next chunk.
+func (d *decryptReader) next() error {
+	var (
+		line []byte
+		err  error
+	)
+	line, err = d.r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return &Error{Err: ErrInvalidPayloadLength, Length: len(line)}
+	}
+	if err == io.EOF && len(line) == 0 {
+		return ErrStreamTruncated
+	}
+	if err != nil && err != io.EOF {
+		return err
+	}
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	// chunks are always version 2 payloads, even if other schemes are registered
+	if d.buf, err = d.c.AppendDecrypt(d.buf[:0], line); err != nil {
+		return err
+	}
+	if len(d.buf) < streamHeaderSize || d.buf[streamIDSize+8] > 1 {
+		return ErrStreamCorrupted
+	}
+	if d.streamID == nil {
+		d.streamID = bytes.Clone(d.buf[:streamIDSize])
+	}
+	if !bytes.Equal(d.streamID, d.buf[:streamIDSize]) || binary.BigEndian.Uint64(d.buf[streamIDSize:]) != d.index {
+		return ErrStreamCorrupted
+	}
+	d.index++
+	d.final = d.buf[streamIDSize+8] == 1
+	d.data = d.buf[streamHeaderSize:]
+	return nil
+}
+
+// end checks that nothing follows the final chunk.
+func (d *decryptReader) end() error {
+	d.c.Wipe()
+	if _, err := d.r.ReadByte(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return ErrStreamCorrupted
+	}
+	return io.EOF
+}
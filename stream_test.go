@@ -0,0 +1,110 @@
+package nip44_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptStream(t *testing.T, convKey []byte, plaintext []byte) []byte {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+		err error
+	)
+	if w, err = nip44.NewEncryptWriter(&buf, convKey); err != nil {
+		t.Fatalf("creating writer failed: %v", err)
+	}
+	// write in odd sizes to not align with chunk boundaries
+	if _, err = io.CopyBuffer(w, bytes.NewReader(plaintext), make([]byte, 1000)); err != nil {
+		t.Fatalf("encryption failed: %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("encryption failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(convKey []byte, stream []byte) ([]byte, error) {
+	r, err := nip44.NewDecryptReader(iotest.HalfReader(bytes.NewReader(stream)), convKey)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStream(t *testing.T) {
+	var (
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		chunk      = nip44.StreamChunkSize
+	)
+	for _, v := range []struct {
+		size   int
+		chunks int
+	}{
+		{0, 1}, {1, 1}, {chunk, 1}, {chunk + 1, 2}, {3*chunk + 5, 4},
+	} {
+		plaintext := bytes.Repeat([]byte{'a'}, v.size)
+		stream := encryptStream(t, convKey, plaintext)
+		assert.Equal(t, v.chunks, bytes.Count(stream, []byte{'\n'}))
+		actual, err := decryptStream(convKey, stream)
+		if assert.NoErrorf(t, err, "decryption failed for size %d: %v", v.size, err) {
+			assert.Equal(t, plaintext, actual)
+		}
+	}
+}
+
+func TestStreamFail(t *testing.T) {
+	var (
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		plaintext  = bytes.Repeat([]byte{'a'}, 2*nip44.StreamChunkSize+1)
+		lines      = bytes.SplitAfter(encryptStream(t, convKey, plaintext), []byte{'\n'})[:3]
+		other      = bytes.SplitAfter(encryptStream(t, convKey, plaintext), []byte{'\n'})[:3]
+		tampered   = bytes.Clone(lines[2])
+		err        error
+	)
+	// change the salt
+	if tampered[10] = 'A'; lines[2][10] == 'A' {
+		tampered[10] = 'B'
+	}
+	for name, v := range map[string]struct {
+		stream   [][]byte
+		expected error
+	}{
+		"empty":         {nil, nip44.ErrStreamTruncated},
+		"truncated":     {lines[:2], nip44.ErrStreamTruncated},
+		"reordered":     {[][]byte{lines[1], lines[0], lines[2]}, nip44.ErrStreamCorrupted},
+		"dropped":       {[][]byte{lines[0], lines[2]}, nip44.ErrStreamCorrupted},
+		"other stream":  {[][]byte{lines[0], other[1], lines[2]}, nip44.ErrStreamCorrupted},
+		"trailing data": {[][]byte{lines[0], lines[1], lines[2], other[0]}, nip44.ErrStreamCorrupted},
+		"tampered":      {[][]byte{lines[0], lines[1], tampered}, nip44.ErrInvalidMAC},
+	} {
+		_, err = decryptStream(convKey, bytes.Join(v.stream, nil))
+		assert.ErrorIsf(t, err, v.expected, "%s", name)
+	}
+	_, err = nip44.NewEncryptWriter(io.Discard, convKey, nip44.WithSalt(make([]byte, 32)))
+	assert.ErrorIs(t, err, nip44.ErrSaltNotAllowed)
+	w, err := nip44.NewEncryptWriter(io.Discard, convKey)
+	if assert.NoErrorf(t, err, "creating writer failed: %v", err) {
+		assert.NoError(t, w.Close())
+		_, err = w.Write([]byte("a"))
+		assert.ErrorIs(t, err, nip44.ErrStreamClosed)
+	}
+	_, err = nip44.NewEncryptWriter(io.Discard, convKey, nip44.WithVersion(3))
+	assertError(t, &nip44.Error{Err: nip44.ErrUnknownVersion, Version: 3}, err)
+	// chunks of other versions are rejected even if their scheme is registered
+	nip44.RegisterScheme(3, plainScheme{version: 3})
+	defer nip44.UnregisterScheme(3)
+	chunk := append([]byte{3}, make([]byte, 16+8)...)
+	chunk = append(chunk, 1, 'a')
+	_, err = decryptStream(convKey, []byte(base64.StdEncoding.EncodeToString(chunk)+"\n"))
+	assertError(t, &nip44.Error{Err: nip44.ErrUnknownVersion, Version: 3}, err)
+	_, err = nip44.NewDecryptReader(bytes.NewReader(nil), convKey[1:])
+	assert.ErrorIs(t, err, nip44.ErrInvalidConversationKey)
+}
@@ -0,0 +1,164 @@
+package nip44_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+// The timing tests are statistical and the full run takes a while, so it only runs if requested:
+//
+//	go test -run Timing -timing
+//
+// Otherwise, a quick run with few samples and a loose threshold only catches gross leaks.
+var (
+	timing        = flag.Bool("timing", false, "run statistical timing tests with timing.samples measurements")
+	timingSamples = flag.Int("timing.samples", 200000, "number of measurements per timing test")
+)
+
+const (
+	// Welch's t-test threshold above which we consider the two classes to be distinguishable.
+	// dudect uses 4.5 as a warning and 10 as a definite leak; we fail on the latter since
+	// these tests run on noisy, shared machines.
+	timingThreshold      = 10
+	quickTimingSamples   = 5000
+	quickTimingThreshold = 50
+)
+
+// welford keeps a running mean and variance.
+type welford struct {
+	n    float64
+	mean float64
+	m2   float64
+}
+
+func (w *welford) push(x float64) {
+	w.n++
+	delta := x - w.mean
+	w.mean += delta / w.n
+	w.m2 += delta * (x - w.mean)
+}
+
+func (w *welford) variance() float64 {
+	if w.n < 2 {
+		return 0
+	}
+	return w.m2 / (w.n - 1)
+}
+
+func welchT(a *welford, b *welford) float64 {
+	se := math.Sqrt(a.variance()/a.n + b.variance()/b.n)
+	if se == 0 {
+		return 0
+	}
+	return (a.mean - b.mean) / se
+}
+
+// assertConstantTime measures fn on inputs of two classes in random order and fails
+// if Welch's t-test can distinguish the timing distributions. Measurements above the
+// given percentile are cropped since they are mostly caused by scheduling noise.
+func assertConstantTime(t *testing.T, percentile float64, fn func(class int)) {
+	var (
+		samples, threshold = timingParams(t)
+		classes            = make([]byte, samples)
+		durations          = make([]float64, samples)
+		sorted             []float64
+		cutoff             float64
+		stats              [2]welford
+		tStat              float64
+		start              time.Time
+	)
+	if _, err := rand.Read(classes); err != nil {
+		t.Fatalf("random class selection failed: %v", err)
+	}
+	// warm up caches and the branch predictor
+	for i := 0; i < samples/10; i++ {
+		fn(int(classes[i] & 1))
+	}
+	for i := 0; i < samples; i++ {
+		class := int(classes[i] & 1)
+		start = time.Now()
+		fn(class)
+		durations[i] = float64(time.Since(start))
+	}
+	sorted = append(sorted, durations...)
+	sort.Float64s(sorted)
+	cutoff = sorted[int(percentile*float64(samples-1))]
+	for i, d := range durations {
+		if d <= cutoff {
+			stats[classes[i]&1].push(d)
+		}
+	}
+	tStat = welchT(&stats[0], &stats[1])
+	t.Logf("n=%.0f/%.0f mean=%.1fns/%.1fns t=%.2f", stats[0].n, stats[1].n, stats[0].mean, stats[1].mean, tStat)
+	assert.Lessf(t, math.Abs(tStat), threshold, "timing leak detected: |t| = %.2f", math.Abs(tStat))
+}
+
+// timingParams returns the number of measurements and the threshold of the full run
+// if requested with -timing, else of the quick run which is skipped in short mode.
+func timingParams(t *testing.T) (int, float64) {
+	if *timing {
+		return *timingSamples, timingThreshold
+	}
+	if testing.Short() {
+		t.Skip("timing tests skipped in short mode")
+	}
+	return quickTimingSamples, quickTimingThreshold
+}
+
+func TestTimingDecryptMAC(t *testing.T) {
+	var (
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		payload    string
+		decoded    []byte
+		inputs     [2]string
+		err        error
+	)
+	if payload, err = nip44.Encrypt(convKey, "a", &nip44.EncryptOptions{}); err != nil {
+		t.Fatalf("encryption failed: %v", err)
+	}
+	decoded, _ = base64.StdEncoding.DecodeString(payload)
+	// class 0 differs from the correct MAC in the first byte, class 1 in the last byte.
+	// A short-circuiting comparison returns earlier for class 0.
+	for class, i := range []int{len(decoded) - 32, len(decoded) - 1} {
+		tampered := append([]byte{}, decoded...)
+		tampered[i] ^= 0x01
+		inputs[class] = base64.StdEncoding.EncodeToString(tampered)
+	}
+	assertConstantTime(t, 0.9, func(class int) {
+		_, _ = nip44.Decrypt(convKey, inputs[class])
+	})
+}
+
+func TestTimingDecryptBeforeAuth(t *testing.T) {
+	var (
+		convKey, _ = hex.DecodeString("c41c775356fd92eadc63ff5a0dc1da211b268cbea22316767095b2871ea1412d")
+		payload    string
+		decoded    []byte
+		inputs     [2]string
+		err        error
+	)
+	if payload, err = nip44.Encrypt(convKey, "a", &nip44.EncryptOptions{}); err != nil {
+		t.Fatalf("encryption failed: %v", err)
+	}
+	decoded, _ = base64.StdEncoding.DecodeString(payload)
+	// class 0 has a tampered MAC but decrypts to valid padding, class 1 has a tampered
+	// length prefix which decrypts to invalid padding. Both must be rejected by the MAC
+	// check before any secret-dependent validation can make them distinguishable.
+	for class, i := range []int{len(decoded) - 1, 33} {
+		tampered := append([]byte{}, decoded...)
+		tampered[i] ^= 0x80
+		inputs[class] = base64.StdEncoding.EncodeToString(tampered)
+	}
+	assertConstantTime(t, 0.9, func(class int) {
+		_, _ = nip44.Decrypt(convKey, inputs[class])
+	})
+}
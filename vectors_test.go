@@ -0,0 +1,196 @@
+package nip44_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ekzyis/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+// testdata/nip44.vectors.json is the only source of test vectors. It uses the layout of
+// nip44.vectors.json of paulmillr/nip44 but is not yet the upstream file. To update the
+// vectors, replace the file, set vectorsSha256 to its published checksum
+// (269ed0f69e4c192512cc779e78c555090cebc7c785b609e338a62afc3ce25040 for upstream) and add
+// the notes of new invalid decryption vectors to decryptErrors.
+const (
+	vectorsPath   = "testdata/nip44.vectors.json"
+	vectorsSha256 = "cda46861a8e08de5150b9837dbf05e5b8add1b04b66cf4d6ed0cb157b881accb"
+)
+
+type vectors struct {
+	V2 struct {
+		Valid struct {
+			GetConversationKey []struct {
+				Sec1            string `json:"sec1"`
+				Pub2            string `json:"pub2"`
+				ConversationKey string `json:"conversation_key"`
+				Note            string `json:"note"`
+			} `json:"get_conversation_key"`
+			GetMessageKeys struct {
+				ConversationKey string `json:"conversation_key"`
+				Keys            []struct {
+					Nonce       string `json:"nonce"`
+					ChaChaKey   string `json:"chacha_key"`
+					ChaChaNonce string `json:"chacha_nonce"`
+					HmacKey     string `json:"hmac_key"`
+				} `json:"keys"`
+			} `json:"get_message_keys"`
+			CalcPaddedLen  [][2]int `json:"calc_padded_len"`
+			EncryptDecrypt []struct {
+				Sec1            string `json:"sec1"`
+				Sec2            string `json:"sec2"`
+				ConversationKey string `json:"conversation_key"`
+				Nonce           string `json:"nonce"`
+				Plaintext       string `json:"plaintext"`
+				Payload         string `json:"payload"`
+			} `json:"encrypt_decrypt"`
+			EncryptDecryptLongMsg []struct {
+				ConversationKey string `json:"conversation_key"`
+				Nonce           string `json:"nonce"`
+				Pattern         string `json:"pattern"`
+				Repeat          int    `json:"repeat"`
+				PlaintextSha256 string `json:"plaintext_sha256"`
+				PayloadSha256   string `json:"payload_sha256"`
+			} `json:"encrypt_decrypt_long_msg"`
+		} `json:"valid"`
+		Invalid struct {
+			EncryptMsgLengths  []int `json:"encrypt_msg_lengths"`
+			GetConversationKey []struct {
+				Sec1 string `json:"sec1"`
+				Pub2 string `json:"pub2"`
+				Note string `json:"note"`
+			} `json:"get_conversation_key"`
+			Decrypt []struct {
+				ConversationKey string `json:"conversation_key"`
+				Nonce           string `json:"nonce"`
+				Plaintext       string `json:"plaintext"`
+				Payload         string `json:"payload"`
+				Note            string `json:"note"`
+			} `json:"decrypt"`
+		} `json:"invalid"`
+	} `json:"v2"`
+}
+
+func loadVectors(t *testing.T) *vectors {
+	var (
+		data []byte
+		sum  [32]byte
+		v    vectors
+		err  error
+	)
+	if data, err = os.ReadFile(vectorsPath); err != nil {
+		t.Fatalf("failed to read test vectors: %v", err)
+	}
+	if sum = sha256.Sum256(data); hex.EncodeToString(sum[:]) != vectorsSha256 {
+		t.Fatalf("test vectors checksum mismatch: expected %s, got %x", vectorsSha256, sum)
+	}
+	if err = json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("failed to parse test vectors: %v", err)
+	}
+	return &v
+}
+
+// decryptErrors maps the notes of invalid decryption vectors to the error we expect.
+var decryptErrors = map[string]error{
+	"unknown encryption version":   nip44.ErrUnknownVersion,
+	"unknown encryption version 0": &nip44.Error{Err: nip44.ErrUnknownVersion, Version: 0},
+	"invalid base64":               nip44.ErrInvalidBase64,
+	"invalid MAC":                  nip44.ErrInvalidMAC,
+	"invalid padding":              nip44.ErrInvalidPadding,
+	"invalid payload length: 0":    &nip44.Error{Err: nip44.ErrInvalidPayloadLength, Length: 0},
+	"invalid payload length: 4":    &nip44.Error{Err: nip44.ErrInvalidPayloadLength, Length: 4},
+	"invalid payload length: 48":   &nip44.Error{Err: nip44.ErrInvalidPayloadLength, Length: 48},
+	"invalid payload length: 92":   &nip44.Error{Err: nip44.ErrInvalidPayloadLength, Length: 92},
+}
+
+// decryptError returns the error we expect for the note of an invalid decryption vector.
+// Unknown notes fail the test so new vectors are not silently checked less strictly.
+func decryptError(t testing.TB, note string) error {
+	expected, ok := decryptErrors[note]
+	if !ok {
+		t.Fatalf("unknown note of invalid decryption vector: %q", note)
+	}
+	return expected
+}
+
+func TestVectorsConversationKey(t *testing.T) {
+	for i, v := range loadVectors(t).V2.Valid.GetConversationKey {
+		t.Run(fmt.Sprintf("%03d", i+1), func(t *testing.T) {
+			assertConversationKeyGenerationPub(t, v.Sec1, v.Pub2, v.ConversationKey)
+		})
+	}
+}
+
+func TestVectorsMessageKeys(t *testing.T) {
+	var v = loadVectors(t).V2.Valid.GetMessageKeys
+	for i, k := range v.Keys {
+		t.Run(fmt.Sprintf("%03d", i+1), func(t *testing.T) {
+			assertMessageKeyGeneration(t, v.ConversationKey, k.Nonce, k.ChaChaKey, k.ChaChaNonce, k.HmacKey)
+		})
+	}
+}
+
+func TestVectorsCryptPriv(t *testing.T) {
+	for i, v := range loadVectors(t).V2.Valid.EncryptDecrypt {
+		t.Run(fmt.Sprintf("%03d", i+1), func(t *testing.T) {
+			assertCryptPriv(t, v.Sec1, v.Sec2, v.ConversationKey, v.Nonce, v.Plaintext, v.Payload)
+		})
+	}
+}
+
+func TestVectorsCryptLong(t *testing.T) {
+	for i, v := range loadVectors(t).V2.Valid.EncryptDecryptLongMsg {
+		t.Run(fmt.Sprintf("%03d", i+1), func(t *testing.T) {
+			assertCryptLong(t, v.ConversationKey, v.Nonce, v.Pattern, v.Repeat, v.PlaintextSha256, v.PayloadSha256)
+		})
+	}
+}
+
+func TestVectorsEncryptMsgLengthFail(t *testing.T) {
+	var convKey = make([]byte, 32)
+	for _, n := range loadVectors(t).V2.Invalid.EncryptMsgLengths {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			_, err := nip44.Encrypt(convKey, strings.Repeat("a", n))
+			assert.ErrorIsf(t, err, nip44.ErrInvalidPlaintextLength, "encryption of %d bytes should fail", n)
+		})
+	}
+}
+
+func TestVectorsConversationKeyFail(t *testing.T) {
+	for i, v := range loadVectors(t).V2.Invalid.GetConversationKey {
+		t.Run(fmt.Sprintf("%03d", i+1), func(t *testing.T) {
+			expected := nip44.ErrInvalidPublicKey
+			if strings.HasPrefix(v.Note, "sec1") {
+				expected = nip44.ErrInvalidPrivateKey
+			}
+			assertConversationKeyFail(t, v.Sec1, v.Pub2, expected)
+		})
+	}
+}
+
+func TestVectorsDecryptFail(t *testing.T) {
+	for i, v := range loadVectors(t).V2.Invalid.Decrypt {
+		t.Run(fmt.Sprintf("%03d", i+1), func(t *testing.T) {
+			assertDecryptFail(t, v.ConversationKey, v.Plaintext, v.Payload, decryptError(t, v.Note))
+		})
+	}
+}
+
+func TestVectorsCalcPaddedLen(t *testing.T) {
+	for _, v := range loadVectors(t).V2.Valid.CalcPaddedLen {
+		assert.Equalf(t, v[1], nip44.CalcPadding(v[0]), "wrong padded length for %d", v[0])
+		if v[0] > nip44.MaxPlaintextSize {
+			continue
+		}
+		paddedLen, err := nip44.PaddedLen(v[0])
+		if assert.NoErrorf(t, err, "padded length calculation failed for %d: %v", v[0], err) {
+			assert.Equalf(t, v[1], paddedLen, "wrong padded length for %d", v[0])
+		}
+	}
+}